@@ -1,12 +1,21 @@
 package main
 
 import (
+	"errors"
 	"fmt"
 	"log/slog"
+	"maps"
 	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
 
 	"github.com/alecthomas/kong"
 	"github.com/mazrean/mcp-tarmaq/mcp"
+	"github.com/mazrean/mcp-tarmaq/mcp/prompts"
+	"github.com/mazrean/mcp-tarmaq/mcp/resources"
 	"github.com/mazrean/mcp-tarmaq/mcp/tools"
 	"github.com/mazrean/mcp-tarmaq/tarmaq"
 )
@@ -18,13 +27,66 @@ var (
 
 // CLI represents command line options and configuration file values
 var CLI struct {
-	Version        kong.VersionFlag `kong:"short='v',help='Show version and exit.'"`
-	LogLevel       string           `kong:"short='l',default='info',enum='debug,info,warn,error',help='Log level',env='MCP_TARMAQ_LOG_LEVEL'"`
-	RepositoryPath string           `kong:"short='r',help='Path to the repository',env='MCP_TARMAQ_REPOSITORY_PATH'"`
-	CommitLimit    int              `kong:"default='0',help='Limit of commits to analyze',env='MCP_TARMAQ_COMMIT_LIMIT'"`
-	MaxChangedFile int              `kong:"default='30',help='Limit of changed files in a commit',env='MCP_TARMAQ_MAX_CHANGED_FILE'"`
-	MinConfidence  float64          `kong:"default='0',help='Minimum confidence value for association rule mining',env='MCP_TARMAQ_MIN_CONFIDENCE'"`
-	MinSupport     float64          `kong:"default='0',help='Minimum support value for association rule mining',env='MCP_TARMAQ_MIN_SUPPORT'"`
+	Version         kong.VersionFlag `kong:"short='v',help='Show version and exit.'"`
+	LogLevel        string           `kong:"short='l',default='info',enum='debug,info,warn,error',help='Log level',env='MCP_TARMAQ_LOG_LEVEL'"`
+	Backend         string           `kong:"default='go-git',enum='go-git,git,changelog,svn,fast-import',help='Backend to read the history with (go-git, git which runs the git command and supports partial clones, or changelog, svn and fast-import which read .jsonl, .json or .csv change logs, svnadmin dumps and fast-import streams given as repository paths)',env='MCP_TARMAQ_BACKEND'"`
+	SVNSubdirectory string           `kong:"name='svn-subdirectory',help='Directory of the Subversion repository to mine with the svn backend (e.g. trunk)',env='MCP_TARMAQ_SVN_SUBDIRECTORY'"`
+	RepositoryPath  string           `kong:"short='r',help='Path to the repository',env='MCP_TARMAQ_REPOSITORY_PATH'"`
+	Repository      []string         `kong:"help='Named repository to serve as name=path (repeatable)',env='MCP_TARMAQ_REPOSITORY'"`
+	CommitLimit     int              `kong:"default='0',help='Limit of commits to analyze',env='MCP_TARMAQ_COMMIT_LIMIT'"`
+	MaxChangedFile  int              `kong:"default='30',help='Limit of changed files in a commit',env='MCP_TARMAQ_MAX_CHANGED_FILE'"`
+	MinConfidence   float64          `kong:"default='0',help='Minimum confidence value for association rule mining',env='MCP_TARMAQ_MIN_CONFIDENCE'"`
+	MinSupport      float64          `kong:"default='0',help='Minimum support value for association rule mining',env='MCP_TARMAQ_MIN_SUPPORT'"`
+	Churn           bool             `kong:"default='false',help='Collect changed lines per file for churn weighted hotspots',env='MCP_TARMAQ_CHURN'"`
+	RenameScore     uint             `kong:"default='60',help='Similarity threshold in percent for rename detection (0 disables it)',env='MCP_TARMAQ_RENAME_SCORE'"`
+	Ref             []string         `kong:"help='Refs to mine the history from instead of HEAD (e.g. origin/main)',env='MCP_TARMAQ_REF'"`
+	AllRefs         bool             `kong:"default='false',help='Mine the history of all local and remote branches',env='MCP_TARMAQ_ALL_REFS'"`
+	Submodules      bool             `kong:"default='false',help='Serve checked out submodules as separate repositories named <name>/<path>',env='MCP_TARMAQ_SUBMODULES'"`
+	IgnoreCase      bool             `kong:"default='false',help='Match file paths given by clients to the history ignoring case, as on case-insensitive file systems',env='MCP_TARMAQ_IGNORE_CASE'"`
+	Author          []string         `kong:"help='Email addresses of the user to mine the history of the user with (default: user.email of git)',env='MCP_TARMAQ_AUTHOR'"`
+	ExcludeMessage  []string         `kong:"sep='none',help='Regular expression of messages of commits to exclude, such as mechanical changes (repeatable, e.g. ^chore\\(deps\\))',env='MCP_TARMAQ_EXCLUDE_MESSAGE'"`
+	BotPattern      string           `kong:"default='${bot_pattern}',help='Regular expression of email addresses of bots whose commits are excluded (disabled if empty)',env='MCP_TARMAQ_BOT_PATTERN'"`
+
+	Model        string `kong:"help='Path to a model exported by the export command to serve instead of mining the repository',env='MCP_TARMAQ_MODEL'"`
+	Deepen       int    `kong:"default='0',help='Fetch N more commits with git fetch --deepen when the repository is a shallow clone (0 disables it)',env='MCP_TARMAQ_DEEPEN'"`
+	ShallowModel string `kong:"help='Path to a model exported by the export command to serve instead of mining the repository of --repository-path when it is a shallow clone',env='MCP_TARMAQ_SHALLOW_MODEL'"`
+
+	CrossRepository string        `kong:"help='Name of a repository joining commits of all repositories to mine coupling across them (disabled if empty)',env='MCP_TARMAQ_CROSS_REPOSITORY'"`
+	TicketPattern   string        `kong:"default='${ticket_pattern}',help='Regular expression of ticket keys joining commits across repositories (disabled if empty)',env='MCP_TARMAQ_TICKET_PATTERN'"`
+	AuthorWindow    time.Duration `kong:"default='1h',help='Time window joining commits of the same author across repositories (0 disables it)',env='MCP_TARMAQ_AUTHOR_WINDOW'"`
+
+	Serve   struct{}   `kong:"cmd,default='1',help='Run the MCP server on stdio (default).'"`
+	Graph   GraphCmd   `kong:"cmd,help='Export the co-change coupling graph of files.'"`
+	Export  ExportCmd  `kong:"cmd,help='Export the mined history as a model to serve with --model.'"`
+	Import  ImportCmd  `kong:"cmd,help='Validate a model exported by the export command and copy it to serve with --model.'"`
+	Inspect InspectCmd `kong:"cmd,help='Print the metadata of a model exported by the export command.'"`
+}
+
+// ExportCmd represents options of the export command
+type ExportCmd struct {
+	Output string `kong:"short='o',required,help='Output file path of the model'"`
+	Name   string `kong:"help='Name of the repository to export when several repositories are configured'"`
+}
+
+// ImportCmd represents options of the import command
+type ImportCmd struct {
+	Model  string `kong:"arg,help='Path to the model'"`
+	Output string `kong:"short='o',required,help='Output file path of the model to serve with --model'"`
+}
+
+// InspectCmd represents options of the inspect command
+type InspectCmd struct {
+	Model string `kong:"arg,help='Path to the model'"`
+}
+
+// GraphCmd represents options of the graph command
+type GraphCmd struct {
+	Format      string  `kong:"short='f',default='dot',enum='dot,graphml,json',help='Output format (dot, graphml or json)'"`
+	Output      string  `kong:"short='o',help='Output file path (default: stdout)'"`
+	Subtree     string  `kong:"help='Only include files under this directory'"`
+	MinCoChange uint64  `kong:"default='1',help='Minimum number of commits changing both files'"`
+	MinStrength float64 `kong:"default='0',help='Minimum co-change strength (Jaccard index) of an edge'"`
+	Name        string  `kong:"help='Name of the repository to export when several repositories are configured'"`
 }
 
 // loadConfig loads and parses configuration from command line arguments
@@ -33,7 +95,11 @@ func loadConfig() (*kong.Context, error) {
 	parser := kong.Must(&CLI,
 		kong.Name("mcp-tarmaq"),
 		kong.Description("A Model Context Protocol (MCP) server that suggests files related to files that have already been modified."),
-		kong.Vars{"version": fmt.Sprintf("%s (%s)", version, revision)},
+		kong.Vars{
+			"version":        fmt.Sprintf("%s (%s)", version, revision),
+			"ticket_pattern": tarmaq.DefaultTicketPattern.String(),
+			"bot_pattern":    tarmaq.DefaultBotPattern.String(),
+		},
 		kong.UsageOnError(),
 	)
 	ctx, err := parser.Parse(os.Args[1:])
@@ -44,22 +110,591 @@ func loadConfig() (*kong.Context, error) {
 	return ctx, nil
 }
 
-func createTarmaq() (*tarmaq.Tarmaq, error) {
-	repo, err := tarmaq.NewGitRepository(CLI.RepositoryPath, CLI.CommitLimit)
+// submoduleRepository is a repository read by a git backend.
+type submoduleRepository interface {
+	Submodules() (map[string]string, error)
+}
+
+// fileBackend reports whether the backend reads the history from a file instead of a working tree.
+func fileBackend() bool {
+	switch CLI.Backend {
+	case "changelog", "svn", "fast-import":
+		return true
+	default:
+		return false
+	}
+}
+
+func createRepository(path string) (tarmaq.Repository, error) {
+	switch CLI.Backend {
+	case "changelog":
+		repo, err := tarmaq.NewChangeLogRepository(path, CLI.CommitLimit)
+		if err != nil {
+			return nil, fmt.Errorf("create change log repository: %w", err)
+		}
+
+		return repo, nil
+	case "svn":
+		repo, err := tarmaq.NewSVNDumpRepository(path, CLI.CommitLimit, tarmaq.WithSVNSubdirectory(CLI.SVNSubdirectory))
+		if err != nil {
+			return nil, fmt.Errorf("create svn dump repository: %w", err)
+		}
+
+		return repo, nil
+	case "fast-import":
+		repo, err := tarmaq.NewFastImportRepository(path, CLI.CommitLimit)
+		if err != nil {
+			return nil, fmt.Errorf("create fast-import repository: %w", err)
+		}
+
+		return repo, nil
+	}
+
+	options := []tarmaq.GitRepositoryOption{
+		tarmaq.WithRenameScore(CLI.RenameScore),
+	}
+	if CLI.Churn {
+		options = append(options, tarmaq.WithChurn())
+	}
+	if len(CLI.Ref) > 0 {
+		options = append(options, tarmaq.WithRefs(CLI.Ref...))
+	}
+	if CLI.AllRefs {
+		options = append(options, tarmaq.WithAllRefs())
+	}
+
+	switch CLI.Backend {
+	case "git":
+		repo, err := tarmaq.NewGitCLIRepository(path, CLI.CommitLimit, options...)
+		if err != nil {
+			return nil, fmt.Errorf("create git cli repository: %w", err)
+		}
+
+		return repo, nil
+	default:
+		repo, err := tarmaq.NewGitRepository(path, CLI.CommitLimit, options...)
+		if err != nil {
+			return nil, fmt.Errorf("create git repository: %w", err)
+		}
+
+		return repo, nil
+	}
+}
+
+// createModelRepository loads the model at path, warning if it was not mined from the current revision of the repository at root
+func createModelRepository(path string, root string) (tarmaq.Repository, error) {
+	snapshot, err := tarmaq.ReadSnapshotFile(path)
 	if err != nil {
-		return nil, fmt.Errorf("create git repository: %w", err)
+		return nil, fmt.Errorf("read model: %w", err)
 	}
 
-	tarmaq := tarmaq.NewTarmaq(repo, []tarmaq.TxFilter{
-		tarmaq.NewMaxSizeTxFilter(CLI.MaxChangedFile),
-		tarmaq.NewTarmaqTxFilter(),
-	}, tarmaq.NewAssociationRuleExtractor(CLI.MinConfidence, uint64(CLI.MinSupport)))
+	if source, err := currentSource(root); err == nil && snapshot.Source != "" && source != snapshot.Source {
+		slog.Warn("model is not mined from the current revision",
+			slog.String("model", path),
+			slog.String("source", snapshot.Source),
+			slog.String("current", source),
+		)
+	}
 
-	return tarmaq, nil
+	return tarmaq.NewSnapshotRepository(snapshot), nil
+}
+
+// currentSource returns the revision of the repository at root, which a model is mined from
+func currentSource(root string) (string, error) {
+	repo, err := createRepository(root)
+	if err != nil {
+		return "", err
+	}
+
+	resolver, ok := repo.(tarmaq.HeadResolver)
+	if !ok {
+		return "", errors.New("revision of the repository is unknown")
+	}
+
+	return resolver.Head()
+}
+
+// handleShallow deepens a shallow clone with --deepen, or serves the model at shallowModel instead of it.
+// It returns the repository to serve and the number of commits mined from the shallow clone, which is 0 if the history is complete.
+func handleShallow(name string, path string, shallowModel string, repo tarmaq.Repository) (tarmaq.Repository, int, error) {
+	reporter, ok := repo.(tarmaq.ShallowReporter)
+	if !ok {
+		return repo, 0, nil
+	}
+
+	depth, shallow, err := reporter.Depth()
+	if err != nil {
+		return nil, 0, fmt.Errorf("get depth: %w", err)
+	}
+	if !shallow {
+		return repo, 0, nil
+	}
+
+	if CLI.Deepen > 0 {
+		slog.Info("deepen shallow clone",
+			slog.String("repository", name),
+			slog.Int("depth", depth),
+			slog.Int("deepen", CLI.Deepen),
+		)
+		if err := reporter.Deepen(CLI.Deepen); err != nil {
+			slog.Warn("failed to deepen shallow clone",
+				slog.String("repository", name),
+				slog.String("error", err.Error()),
+			)
+		} else {
+			// reopen the repository to read the fetched commits
+			repo, err = createRepository(path)
+			if err != nil {
+				return nil, 0, fmt.Errorf("create repository: %w", err)
+			}
+			reporter, ok = repo.(tarmaq.ShallowReporter)
+			if !ok {
+				return repo, 0, nil
+			}
+
+			depth, shallow, err = reporter.Depth()
+			if err != nil {
+				return nil, 0, fmt.Errorf("get depth: %w", err)
+			}
+			if !shallow {
+				return repo, 0, nil
+			}
+		}
+	}
+
+	if shallowModel != "" {
+		slog.Info("serve model instead of shallow clone",
+			slog.String("repository", name),
+			slog.String("model", shallowModel),
+		)
+		repo, err := createModelRepository(shallowModel, path)
+		if err != nil {
+			return nil, 0, fmt.Errorf("create model repository: %w", err)
+		}
+
+		return repo, 0, nil
+	}
+
+	slog.Warn("repository is a shallow clone, so the history is incomplete",
+		slog.String("repository", name),
+		slog.Int("depth", depth),
+	)
+
+	return repo, depth, nil
+}
+
+// createUserTxFilter creates the filter of the history of the user of --author, or user.email of the repository.
+// It returns nil if the user is unknown.
+func createUserTxFilter(name string, repo tarmaq.Repository) (*tarmaq.AuthorTxFilter, error) {
+	authors := CLI.Author
+	var mailmap *tarmaq.Mailmap
+	if reporter, ok := repo.(tarmaq.AuthorReporter); ok {
+		if len(authors) == 0 {
+			identity, err := reporter.Identity()
+			if err != nil {
+				return nil, fmt.Errorf("get identity: %w", err)
+			}
+			if identity != "" {
+				authors = []string{identity}
+			}
+		}
+
+		var err error
+		mailmap, err = reporter.Mailmap()
+		if err != nil {
+			return nil, fmt.Errorf("get mailmap: %w", err)
+		}
+	}
+	if len(authors) == 0 {
+		slog.Debug("user is unknown",
+			slog.String("repository", name),
+		)
+		return nil, nil
+	}
+
+	return tarmaq.NewAuthorTxFilter(authors, mailmap), nil
+}
+
+// createHistoryTxFilters creates the filters that do not depend on the query
+func createHistoryTxFilters() ([]tarmaq.TxFilter, error) {
+	var filters []tarmaq.TxFilter
+	if len(CLI.ExcludeMessage) > 0 {
+		patterns := make([]*regexp.Regexp, 0, len(CLI.ExcludeMessage))
+		for _, message := range CLI.ExcludeMessage {
+			pattern, err := regexp.Compile(message)
+			if err != nil {
+				return nil, fmt.Errorf("compile message pattern: %w", err)
+			}
+			patterns = append(patterns, pattern)
+		}
+		filters = append(filters, tarmaq.NewMessageTxFilter(patterns...))
+	}
+	if CLI.BotPattern != "" {
+		pattern, err := regexp.Compile(CLI.BotPattern)
+		if err != nil {
+			return nil, fmt.Errorf("compile bot pattern: %w", err)
+		}
+		filters = append(filters, tarmaq.NewBotTxFilter(pattern))
+	}
+	filters = append(filters, tarmaq.NewMaxSizeTxFilter(CLI.MaxChangedFile))
+
+	return filters, nil
+}
+
+func createTarmaq(repo tarmaq.Repository, txFilters []tarmaq.TxFilter) *tarmaq.Tarmaq {
+	return tarmaq.NewTarmaq(
+		repo,
+		slices.Concat(txFilters, []tarmaq.TxFilter{tarmaq.NewTarmaqTxFilter()}),
+		tarmaq.NewAssociationRuleExtractor(CLI.MinConfidence, uint64(CLI.MinSupport)),
+	)
+}
+
+// createRepositories creates the repository of --repository-path and the named repositories of --repository
+func createRepositories() ([]*tools.Repository, error) {
+	type namedPath struct {
+		name string
+		path string
+		// model is the path to the model served instead of mining the repository
+		model string
+		// shallowModel is the path to the model served if the repository is a shallow clone
+		shallowModel string
+	}
+
+	var paths []namedPath
+	if CLI.RepositoryPath != "" || len(CLI.Repository) == 0 {
+		root, err := filepath.Abs(CLI.RepositoryPath)
+		if err != nil {
+			return nil, fmt.Errorf("get absolute path: %w", err)
+		}
+		name := filepath.Base(root)
+		if fileBackend() {
+			name = strings.TrimSuffix(name, filepath.Ext(name))
+		}
+		paths = append(paths, namedPath{name: name, path: root, model: CLI.Model, shallowModel: CLI.ShallowModel})
+	}
+	for _, repository := range CLI.Repository {
+		name, path, ok := strings.Cut(repository, "=")
+		if !ok || name == "" || path == "" {
+			return nil, fmt.Errorf("invalid repository (expected name=path): %s", repository)
+		}
+
+		root, err := filepath.Abs(path)
+		if err != nil {
+			return nil, fmt.Errorf("get absolute path: %w", err)
+		}
+		paths = append(paths, namedPath{name: name, path: root})
+	}
+
+	names := make(map[string]struct{}, len(paths))
+	repositories := make([]*tools.Repository, 0, len(paths))
+	for i := 0; i < len(paths); i++ {
+		path := paths[i]
+		if _, ok := names[path.name]; ok {
+			return nil, fmt.Errorf("duplicate repository name: %s", path.name)
+		}
+		names[path.name] = struct{}{}
+
+		var repo tarmaq.Repository
+		var err error
+		if path.model != "" {
+			repo, err = createModelRepository(path.model, path.path)
+		} else {
+			repo, err = createRepository(path.path)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("create repository %s: %w", path.name, err)
+		}
+
+		repo, shallowDepth, err := handleShallow(path.name, path.path, path.shallowModel, repo)
+		if err != nil {
+			return nil, fmt.Errorf("check shallow clone %s: %w", path.name, err)
+		}
+
+		txFilters, err := createHistoryTxFilters()
+		if err != nil {
+			return nil, fmt.Errorf("create filters: %w", err)
+		}
+		userTxFilter, err := createUserTxFilter(path.name, repo)
+		if err != nil {
+			return nil, fmt.Errorf("create user filter of %s: %w", path.name, err)
+		}
+
+		root := path.path
+		if fileBackend() && path.model == "" {
+			root = ""
+		}
+		repositories = append(repositories, &tools.Repository{
+			Name:       path.name,
+			Root:       root,
+			Repository: repo,
+			TxFilters:  txFilters,
+			Tarmaq:     createTarmaq(repo, txFilters),
+
+			ShallowDepth: shallowDepth,
+			IgnoreCase:   CLI.IgnoreCase,
+			UserTxFilter: userTxFilter,
+		})
+
+		if subRepo, ok := repo.(submoduleRepository); ok && CLI.Submodules {
+			// submodules have their own histories, so they are served as separate repositories
+			submodules, err := subRepo.Submodules()
+			if err != nil {
+				return nil, fmt.Errorf("get submodules of %s: %w", path.name, err)
+			}
+			subPaths := slices.Sorted(maps.Keys(submodules))
+			for _, subPath := range subPaths {
+				paths = append(paths, namedPath{name: path.name + "/" + subPath, path: submodules[subPath]})
+			}
+		}
+	}
+
+	if CLI.CrossRepository != "" {
+		if _, ok := names[CLI.CrossRepository]; ok {
+			return nil, fmt.Errorf("duplicate repository name: %s", CLI.CrossRepository)
+		}
+
+		crossRepository, err := createCrossRepository(CLI.CrossRepository, repositories)
+		if err != nil {
+			return nil, fmt.Errorf("create cross repository: %w", err)
+		}
+		repositories = append(repositories, crossRepository)
+	}
+
+	return repositories, nil
+}
+
+func createCrossRepository(name string, members []*tools.Repository) (*tools.Repository, error) {
+	var ticketPattern *regexp.Regexp
+	if CLI.TicketPattern != "" {
+		var err error
+		ticketPattern, err = regexp.Compile(CLI.TicketPattern)
+		if err != nil {
+			return nil, fmt.Errorf("compile ticket pattern: %w", err)
+		}
+	}
+
+	names := make([]string, 0, len(members))
+	repos := make([]tarmaq.Repository, 0, len(members))
+	var authors []string
+	var mailmaps []*tarmaq.Mailmap
+	for _, member := range members {
+		names = append(names, member.Name)
+		repos = append(repos, member.Repository)
+		if member.UserTxFilter != nil {
+			authors = append(authors, slices.Collect(member.UserTxFilter.Authors.Iter())...)
+			mailmaps = append(mailmaps, member.UserTxFilter.Mailmap)
+		}
+	}
+	var userTxFilter *tarmaq.AuthorTxFilter
+	if len(authors) > 0 {
+		userTxFilter = tarmaq.NewAuthorTxFilter(authors, tarmaq.MergeMailmaps(mailmaps...))
+	}
+
+	repo, err := tarmaq.NewCrossRepository(names, repos,
+		tarmaq.WithTicketPattern(ticketPattern),
+		tarmaq.WithAuthorWindow(CLI.AuthorWindow),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("create cross repository: %w", err)
+	}
+
+	txFilters, err := createHistoryTxFilters()
+	if err != nil {
+		return nil, fmt.Errorf("create filters: %w", err)
+	}
+
+	return &tools.Repository{
+		Name:       name,
+		Repository: repo,
+		TxFilters:  txFilters,
+		Tarmaq:     createTarmaq(repo, txFilters),
+		Members:    members,
+		IgnoreCase: CLI.IgnoreCase,
+
+		UserTxFilter: userTxFilter,
+	}, nil
+}
+
+func serve() error {
+	repositories, err := createRepositories()
+	if err != nil {
+		return fmt.Errorf("create repositories: %w", err)
+	}
+	repos := tools.NewRepositories(repositories...)
+
+	hotspots := resources.NewHotspotsResource(repos)
+	server := mcp.NewServer(version,
+		mcp.WithTools(
+			tools.NewTarmaqTool(repos),
+			tools.NewHotspotTool(repos),
+			tools.NewCouplingGraphTool(repos),
+			tools.NewCouplingClustersTool(repos),
+			tools.NewCoupledFilesTool(repos),
+			tools.NewFileLineageTool(repos),
+		),
+		mcp.WithResources(
+			hotspots,
+			resources.NewStatsResource(repos),
+		),
+		mcp.WithResourceTemplates(
+			hotspots,
+			resources.NewFileCouplingResource(repos),
+		),
+		mcp.WithPrompts(
+			prompts.NewReviewChangeCompletenessPrompt(repos),
+		),
+	)
+	if err := server.Start(); err != nil {
+		return fmt.Errorf("run server: %w", err)
+	}
+
+	return nil
+}
+
+func exportGraph() error {
+	repositories, err := createRepositories()
+	if err != nil {
+		return fmt.Errorf("create repositories: %w", err)
+	}
+
+	arguments := map[string]any{}
+	if CLI.Graph.Name != "" {
+		arguments["repository"] = CLI.Graph.Name
+	}
+	repo, _, err := tools.NewRepositories(repositories...).Resolve(arguments)
+	if err != nil {
+		return fmt.Errorf("resolve repository: %w", err)
+	}
+
+	transactions, fileMap, err := repo.Repository.GetTransactions()
+	if err != nil {
+		return fmt.Errorf("get transactions: %w", err)
+	}
+	transactions = tarmaq.ApplyTxFilters(transactions, nil, repo.TxFilters)
+
+	graph := tarmaq.NewCouplingGraph(transactions, fileMap, tarmaq.CouplingOptions{
+		Subtree:     tarmaq.NewFilePath(CLI.Graph.Subtree),
+		MinSupport:  CLI.Graph.MinCoChange,
+		MinStrength: CLI.Graph.MinStrength,
+	})
+
+	if CLI.Graph.Output == "" {
+		if err := graph.Write(os.Stdout, tarmaq.GraphFormat(CLI.Graph.Format)); err != nil {
+			return fmt.Errorf("write graph: %w", err)
+		}
+
+		return nil
+	}
+
+	f, err := os.Create(CLI.Graph.Output)
+	if err != nil {
+		return fmt.Errorf("create output file: %w", err)
+	}
+	if err := graph.Write(f, tarmaq.GraphFormat(CLI.Graph.Format)); err != nil {
+		f.Close()
+		return fmt.Errorf("write graph: %w", err)
+	}
+	if err := f.Close(); err != nil {
+		return fmt.Errorf("close output file: %w", err)
+	}
+
+	return nil
+}
+
+func exportModel() error {
+	repositories, err := createRepositories()
+	if err != nil {
+		return fmt.Errorf("create repositories: %w", err)
+	}
+
+	arguments := map[string]any{}
+	if CLI.Export.Name != "" {
+		arguments["repository"] = CLI.Export.Name
+	}
+	repo, _, err := tools.NewRepositories(repositories...).Resolve(arguments)
+	if err != nil {
+		return fmt.Errorf("resolve repository: %w", err)
+	}
+
+	snapshot, err := tarmaq.NewSnapshot(repo.Repository)
+	if err != nil {
+		return fmt.Errorf("create model: %w", err)
+	}
+
+	f, err := os.Create(CLI.Export.Output)
+	if err != nil {
+		return fmt.Errorf("create output file: %w", err)
+	}
+
+	if err := snapshot.Write(f); err != nil {
+		f.Close()
+		return fmt.Errorf("write model: %w", err)
+	}
+	// a model truncated by a failed close must not be reported as exported
+	if err := f.Close(); err != nil {
+		return fmt.Errorf("close output file: %w", err)
+	}
+
+	return nil
+}
+
+func importModel() error {
+	// reading the model rejects broken models and models of other schema versions
+	snapshot, err := tarmaq.ReadSnapshotFile(CLI.Import.Model)
+	if err != nil {
+		return fmt.Errorf("read model: %w", err)
+	}
+
+	if err := printModel(snapshot); err != nil {
+		return fmt.Errorf("print model: %w", err)
+	}
+
+	f, err := os.Create(CLI.Import.Output)
+	if err != nil {
+		return fmt.Errorf("create output file: %w", err)
+	}
+
+	if err := snapshot.Write(f); err != nil {
+		f.Close()
+		return fmt.Errorf("write model: %w", err)
+	}
+	if err := f.Close(); err != nil {
+		return fmt.Errorf("close output file: %w", err)
+	}
+
+	return nil
+}
+
+func inspectModel() error {
+	snapshot, err := tarmaq.ReadSnapshotFile(CLI.Inspect.Model)
+	if err != nil {
+		return fmt.Errorf("read model: %w", err)
+	}
+
+	return printModel(snapshot)
+}
+
+// printModel prints the metadata of the model and whether it is up to date with --repository-path.
+func printModel(snapshot *tarmaq.Snapshot) error {
+	fmt.Printf("schema version: %d\n", snapshot.SchemaVersion)
+	fmt.Printf("source: %s\n", snapshot.Source)
+	fmt.Printf("created at: %s\n", snapshot.CreatedAt.Format(time.RFC3339))
+	fmt.Printf("transactions: %d\n", len(snapshot.Transactions))
+	fmt.Printf("files: %d\n", len(snapshot.FileMap))
+
+	if CLI.RepositoryPath != "" {
+		source, err := currentSource(CLI.RepositoryPath)
+		if err != nil {
+			return fmt.Errorf("get revision of the repository: %w", err)
+		}
+		fmt.Printf("up to date: %t\n", source == snapshot.Source)
+	}
+
+	return nil
 }
 
 func main() {
-	_, err := loadConfig()
+	ctx, err := loadConfig()
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "error: %v\n", err)
 		os.Exit(1)
@@ -80,17 +715,21 @@ func main() {
 		Level: level,
 	})))
 
-	executer, err := createTarmaq()
-	if err != nil {
-		slog.Error("failed to create tarmaq",
-			slog.String("error", err.Error()),
-		)
-		os.Exit(1)
+	switch ctx.Command() {
+	case "graph":
+		err = exportGraph()
+	case "export":
+		err = exportModel()
+	case "import <model>":
+		err = importModel()
+	case "inspect <model>":
+		err = inspectModel()
+	default:
+		err = serve()
 	}
-
-	server := mcp.NewServer(version, tools.NewTarmaqTool(executer))
-	if err := server.Start(); err != nil {
-		slog.Error("failed to run server",
+	if err != nil {
+		slog.Error("failed to run command",
+			slog.String("command", ctx.Command()),
 			slog.String("error", err.Error()),
 		)
 		os.Exit(1)
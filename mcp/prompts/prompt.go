@@ -0,0 +1,12 @@
+package prompts
+
+import (
+	"context"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+type Prompt interface {
+	Prompt() mcp.Prompt
+	Handle(ctx context.Context, request mcp.GetPromptRequest) (*mcp.GetPromptResult, error)
+}
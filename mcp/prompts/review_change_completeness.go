@@ -0,0 +1,185 @@
+package prompts
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/mcp"
+
+	"github.com/mazrean/mcp-tarmaq/mcp/tools"
+	"github.com/mazrean/mcp-tarmaq/tarmaq"
+)
+
+var _ Prompt = &ReviewChangeCompletenessPrompt{}
+
+// ReviewChangeCompletenessPrompt asks the model to verify the files which usually change together with the current changes.
+type ReviewChangeCompletenessPrompt struct {
+	repositories *tools.Repositories
+}
+
+func NewReviewChangeCompletenessPrompt(repositories *tools.Repositories) *ReviewChangeCompletenessPrompt {
+	return &ReviewChangeCompletenessPrompt{
+		repositories: repositories,
+	}
+}
+
+func (h *ReviewChangeCompletenessPrompt) Prompt() mcp.Prompt {
+	return mcp.NewPrompt("review_change_completeness",
+		mcp.WithPromptDescription("Review whether the current changes miss files that historically change together with them"),
+		mcp.WithArgument("repository",
+			mcp.ArgumentDescription(fmt.Sprintf(
+				"name of the repository (%s). It can be omitted if there is only one repository",
+				strings.Join(h.repositories.Names(), ", "),
+			)),
+		),
+		mcp.WithArgument("files",
+			mcp.ArgumentDescription("changed files separated by commas (default: uncommitted changes in the working tree)"),
+		),
+		mcp.WithArgument("limit",
+			mcp.ArgumentDescription("maximum number of files to verify (default: 20)"),
+		),
+	)
+}
+
+func (h *ReviewChangeCompletenessPrompt) Handle(_ context.Context, request mcp.GetPromptRequest) (*mcp.GetPromptResult, error) {
+	arguments := map[string]any{}
+	if repository := request.Params.Arguments["repository"]; repository != "" {
+		arguments["repository"] = repository
+	}
+
+	var files []string
+	for _, file := range strings.Split(request.Params.Arguments["files"], ",") {
+		if file = strings.TrimSpace(file); file != "" {
+			files = append(files, file)
+		}
+	}
+
+	limit := 20
+	if iLimit := request.Params.Arguments["limit"]; iLimit != "" {
+		var err error
+		limit, err = strconv.Atoi(iLimit)
+		if err != nil {
+			slog.Error("invalid limit",
+				slog.String("limit", iLimit),
+			)
+			return nil, fmt.Errorf("invalid limit: %w", err)
+		}
+	}
+
+	repo, relFiles, err := h.repositories.Resolve(arguments, files...)
+	if err != nil {
+		slog.Error("resolve repository",
+			slog.String("error", err.Error()),
+		)
+		return nil, fmt.Errorf("resolve repository: %w", err)
+	}
+	style := repo.Style(files)
+	files = relFiles
+
+	if len(files) == 0 {
+		reporter, ok := repo.Repository.(tarmaq.WorktreeReporter)
+		if !ok {
+			slog.Error("no working tree",
+				slog.String("repository", repo.Name),
+			)
+			return nil, errors.New("repository has no working tree, so files are required")
+		}
+
+		changed, err := reporter.ChangedFiles()
+		if err != nil {
+			slog.Error("get changed files",
+				slog.String("error", err.Error()),
+			)
+			return nil, fmt.Errorf("get changed files: %w", err)
+		}
+		for _, file := range changed {
+			files = append(files, string(file))
+		}
+	}
+	if len(files) == 0 {
+		return nil, errors.New("no changed files in the working tree")
+	}
+
+	tarmaqFiles := make([]tarmaq.FilePath, 0, len(files))
+	for _, file := range files {
+		tarmaqFiles = append(tarmaqFiles, tarmaq.NewFilePath(file))
+	}
+
+	var history tarmaq.History
+	options := []tarmaq.ExecuteOption{tarmaq.ExcludeDeleted(), tarmaq.WithHistory(&history)}
+	if repo.IgnoreCase {
+		options = append(options, tarmaq.IgnoreCase())
+	}
+	results, err := repo.Tarmaq.Execute(tarmaqFiles, options...)
+	if err != nil {
+		slog.Error("execute tarmaq",
+			slog.String("error", err.Error()),
+		)
+		return nil, fmt.Errorf("execute tarmaq: %w", err)
+	}
+	if limit > 0 && len(results) > limit {
+		results = results[:limit]
+	}
+
+	transactions := tarmaq.ApplyTxFilters(history.Transactions, nil, repo.TxFilters)
+	tests := tarmaq.TestPairs(transactions, history.FileMap, repo.FoldCase(tarmaqFiles, history.FileMap), limit)
+
+	return mcp.NewGetPromptResult(
+		"Review the completeness of the changes",
+		[]mcp.PromptMessage{
+			mcp.NewPromptMessage(mcp.RoleUser, mcp.NewTextContent(reviewChangeCompletenessText(repo, style, tarmaqFiles, results, tests))),
+		},
+	), nil
+}
+
+func reviewChangeCompletenessText(repo *tools.Repository, style tools.PathStyle, files []tarmaq.FilePath, results []*tarmaq.Result, tests []*tarmaq.TestPair) string {
+	var sb strings.Builder
+
+	fmt.Fprintf(&sb, "I changed the following files of the repository %s:\n\n", repo.Name)
+	for _, file := range files {
+		fmt.Fprintf(&sb, "- %s\n", style.Format(file))
+	}
+	sb.WriteString("\n")
+
+	if len(results) == 0 {
+		sb.WriteString("The change history has no files that usually change together with them. ")
+		sb.WriteString("Review whether the changes are complete on their own, e.g. whether tests, documentation or callers need updates.\n")
+	} else {
+		sb.WriteString("According to the change history, the following files usually change together with them:\n\n")
+		sb.WriteString("| File | Confidence | Support |\n")
+		sb.WriteString("| --- | --- | --- |\n")
+		for _, result := range results {
+			fmt.Fprintf(&sb, "| %s | %.2f | %d |\n", style.Format(result.Path), result.Confidence, result.Support)
+		}
+		sb.WriteString("\n")
+		sb.WriteString("Confidence is the ratio of past commits changing the files above which also changed the suggested file, and support is the number of such commits.\n\n")
+		sb.WriteString("For each suggested file, read it together with my changes and decide whether it also needs to change. ")
+		sb.WriteString("Reply with a checklist that has one item per suggested file, giving the verdict (needs change, no change needed or unsure) and a short reason. ")
+		sb.WriteString("Finally, list the files that need changes in order of importance.\n")
+	}
+
+	if len(tests) > 0 {
+		sb.WriteString("\nThe following tests are likely to need updates, since they are named after or usually change together with the changed files:\n\n")
+		sb.WriteString("| Test | Changed file | Named after it | Confidence |\n")
+		sb.WriteString("| --- | --- | --- | --- |\n")
+		for _, test := range tests {
+			nameMatch := "no"
+			if test.NameMatch {
+				nameMatch = "yes"
+			}
+			fmt.Fprintf(&sb, "| %s | %s | %s | %.2f |\n",
+				style.Format(test.Path), style.Format(test.File), nameMatch, test.Confidence)
+		}
+		sb.WriteString("\nInclude each test in the checklist, and check whether it covers the changed behavior.\n")
+	}
+
+	for _, notice := range repo.Notices() {
+		fmt.Fprintf(&sb, "\nNote: %s\n", notice)
+	}
+
+	return sb.String()
+}
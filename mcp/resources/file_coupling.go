@@ -0,0 +1,74 @@
+package resources
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+
+	"github.com/mark3labs/mcp-go/mcp"
+
+	"github.com/mazrean/mcp-tarmaq/mcp/tools"
+	"github.com/mazrean/mcp-tarmaq/tarmaq"
+)
+
+var _ Template = &FileCouplingResource{}
+
+// FileCouplingResource serves the historical coupling of a file.
+type FileCouplingResource struct {
+	repositories *tools.Repositories
+	limit        int
+}
+
+func NewFileCouplingResource(repositories *tools.Repositories) *FileCouplingResource {
+	return &FileCouplingResource{
+		repositories: repositories,
+		limit:        20,
+	}
+}
+
+func (h *FileCouplingResource) Template() mcp.ResourceTemplate {
+	return mcp.NewResourceTemplate("tarmaq://file/{+path}/coupling{?repository}", "File coupling",
+		mcp.WithTemplateDescription("Files that change when the file changes, and files whose changes drag it along. The path is relative to the repository root, or absolute"),
+		mcp.WithTemplateMIMEType("application/json"),
+	)
+}
+
+func (h *FileCouplingResource) Handle(_ context.Context, request mcp.ReadResourceRequest) ([]mcp.ResourceContents, error) {
+	args := arguments(request)
+	file, ok := args["path"].(string)
+	if !ok || file == "" {
+		slog.Error("invalid path",
+			slog.String("uri", request.Params.URI),
+		)
+		return nil, fmt.Errorf("invalid path: %s", request.Params.URI)
+	}
+	repo, files, err := h.repositories.Resolve(args, file)
+	if err != nil {
+		slog.Error("resolve repository",
+			slog.String("error", err.Error()),
+		)
+		return nil, fmt.Errorf("resolve repository: %w", err)
+	}
+	style := repo.Style([]string{file})
+	file = files[0]
+
+	transactions, fileMap, err := repo.Repository.GetTransactions()
+	if err != nil {
+		slog.Error("get transactions",
+			slog.String("error", err.Error()),
+		)
+		return nil, fmt.Errorf("get transactions: %w", err)
+	}
+	transactions = tarmaq.ApplyTxFilters(transactions, nil, repo.TxFilters)
+
+	path := repo.FoldCase([]tarmaq.FilePath{tarmaq.NewFilePath(file)}, fileMap)[0]
+	coupling, ok := tarmaq.NewFileCoupling(transactions, fileMap, path, 1)
+	if !ok {
+		slog.Error("file not found",
+			slog.String("file", file),
+		)
+		return nil, fmt.Errorf("file not found in history: %s", file)
+	}
+
+	return jsonContents(request.Params.URI, tools.NewCoupledFilesResponse(coupling, h.limit, style))
+}
@@ -0,0 +1,72 @@
+package resources
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+
+	"github.com/mazrean/mcp-tarmaq/mcp/tools"
+	"github.com/mazrean/mcp-tarmaq/tarmaq"
+)
+
+var (
+	_ Resource = &HotspotsResource{}
+	_ Template = &HotspotsResource{}
+)
+
+// HotspotsResource serves the most frequently changed files.
+// tarmaq://hotspots serves the only repository, and tarmaq://hotspots?repository=<name> any of them.
+type HotspotsResource struct {
+	repositories *tools.Repositories
+	limit        int
+}
+
+func NewHotspotsResource(repositories *tools.Repositories) *HotspotsResource {
+	return &HotspotsResource{
+		repositories: repositories,
+		limit:        20,
+	}
+}
+
+func (h *HotspotsResource) Resource() mcp.Resource {
+	return mcp.NewResource("tarmaq://hotspots", "Change hotspots",
+		mcp.WithResourceDescription(fmt.Sprintf("The %d most frequently changed files in the changelog", h.limit)),
+		mcp.WithMIMEType("application/json"),
+	)
+}
+
+func (h *HotspotsResource) Template() mcp.ResourceTemplate {
+	return mcp.NewResourceTemplate("tarmaq://hotspots{?repository}", "Change hotspots of a repository",
+		mcp.WithTemplateDescription(fmt.Sprintf("The %d most frequently changed files in the changelog of the repository", h.limit)),
+		mcp.WithTemplateMIMEType("application/json"),
+	)
+}
+
+func (h *HotspotsResource) Handle(_ context.Context, request mcp.ReadResourceRequest) ([]mcp.ResourceContents, error) {
+	repo, _, err := h.repositories.Resolve(arguments(request))
+	if err != nil {
+		slog.Error("resolve repository",
+			slog.String("error", err.Error()),
+		)
+		return nil, fmt.Errorf("resolve repository: %w", err)
+	}
+
+	transactions, fileMap, err := repo.Repository.GetTransactions()
+	if err != nil {
+		slog.Error("get transactions",
+			slog.String("error", err.Error()),
+		)
+		return nil, fmt.Errorf("get transactions: %w", err)
+	}
+	transactions = tarmaq.ApplyTxFilters(transactions, nil, repo.TxFilters)
+
+	hotspots := tarmaq.Hotspots(transactions, fileMap, tarmaq.HotspotOptions{
+		Now:   time.Now(),
+		Limit: h.limit,
+	})
+
+	return jsonContents(request.Params.URI, tools.NewHotspotResponses(hotspots, repo.Style(nil)))
+}
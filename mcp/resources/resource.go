@@ -0,0 +1,52 @@
+package resources
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+type Resource interface {
+	Resource() mcp.Resource
+	Handle(ctx context.Context, request mcp.ReadResourceRequest) ([]mcp.ResourceContents, error)
+}
+
+type Template interface {
+	Template() mcp.ResourceTemplate
+	Handle(ctx context.Context, request mcp.ReadResourceRequest) ([]mcp.ResourceContents, error)
+}
+
+// arguments returns the variables matched by a template as tool style arguments.
+func arguments(request mcp.ReadResourceRequest) map[string]any {
+	arguments := make(map[string]any, len(request.Params.Arguments))
+	for name, value := range request.Params.Arguments {
+		switch value := value.(type) {
+		case string:
+			arguments[name] = value
+		case []string:
+			if len(value) > 0 {
+				arguments[name] = value[0]
+			}
+		}
+	}
+
+	return arguments
+}
+
+// jsonContents marshals v as the contents of the resource at uri.
+func jsonContents(uri string, v any) ([]mcp.ResourceContents, error) {
+	text, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("marshal contents: %w", err)
+	}
+
+	return []mcp.ResourceContents{
+		mcp.TextResourceContents{
+			URI:      uri,
+			MIMEType: "application/json",
+			Text:     string(text),
+		},
+	}, nil
+}
@@ -0,0 +1,76 @@
+package resources
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+
+	"github.com/mazrean/mcp-tarmaq/mcp/tools"
+	"github.com/mazrean/mcp-tarmaq/tarmaq"
+)
+
+var _ Resource = &StatsResource{}
+
+// StatsResource serves the statistics of the mined history of all repositories.
+type StatsResource struct {
+	repositories *tools.Repositories
+}
+
+func NewStatsResource(repositories *tools.Repositories) *StatsResource {
+	return &StatsResource{
+		repositories: repositories,
+	}
+}
+
+func (h *StatsResource) Resource() mcp.Resource {
+	return mcp.NewResource("tarmaq://stats", "History statistics",
+		mcp.WithResourceDescription("Number of commits, files and authors and the time range of the mined history of each repository"),
+		mcp.WithMIMEType("application/json"),
+	)
+}
+
+type StatsResponse struct {
+	Repository   string    `json:"repository"`
+	Root         string    `json:"root,omitempty"`
+	Commits      int       `json:"commits"`
+	Files        int       `json:"files"`
+	RemovedFiles int       `json:"removed_files"`
+	Authors      int       `json:"authors"`
+	FirstCommit  time.Time `json:"first_commit"`
+	LastCommit   time.Time `json:"last_commit"`
+	Notices      []string  `json:"notices,omitempty"`
+}
+
+func (h *StatsResource) Handle(_ context.Context, request mcp.ReadResourceRequest) ([]mcp.ResourceContents, error) {
+	repositories := h.repositories.All()
+	res := make([]*StatsResponse, 0, len(repositories))
+	for _, repo := range repositories {
+		transactions, fileMap, err := repo.Repository.GetTransactions()
+		if err != nil {
+			slog.Error("get transactions",
+				slog.String("repository", repo.Name),
+				slog.String("error", err.Error()),
+			)
+			return nil, fmt.Errorf("get transactions of %s: %w", repo.Name, err)
+		}
+		transactions = tarmaq.ApplyTxFilters(transactions, nil, repo.TxFilters)
+
+		stats := tarmaq.NewStats(transactions, fileMap)
+		res = append(res, &StatsResponse{
+			Repository:   repo.Name,
+			Root:         repo.Root,
+			Commits:      stats.Transactions,
+			Files:        stats.Files,
+			RemovedFiles: stats.RemovedFiles,
+			Authors:      stats.Authors,
+			FirstCommit:  stats.FirstCommit,
+			LastCommit:   stats.LastCommit,
+			Notices:      repo.Notices(),
+		})
+	}
+
+	return jsonContents(request.Params.URI, res)
+}
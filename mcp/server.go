@@ -2,6 +2,8 @@ package mcp
 
 import (
 	"github.com/mark3labs/mcp-go/server"
+	"github.com/mazrean/mcp-tarmaq/mcp/prompts"
+	"github.com/mazrean/mcp-tarmaq/mcp/resources"
 	"github.com/mazrean/mcp-tarmaq/mcp/tools"
 )
 
@@ -9,9 +11,47 @@ type Server struct {
 	server *server.MCPServer
 }
 
+type ServerOption func(*server.MCPServer)
+
+// WithTools registers the tools.
+func WithTools(tools ...tools.Tool) ServerOption {
+	return func(s *server.MCPServer) {
+		for _, tool := range tools {
+			s.AddTool(tool.Tool(), tool.Handle)
+		}
+	}
+}
+
+// WithResources registers the resources with fixed URIs.
+func WithResources(resources ...resources.Resource) ServerOption {
+	return func(s *server.MCPServer) {
+		for _, resource := range resources {
+			s.AddResource(resource.Resource(), resource.Handle)
+		}
+	}
+}
+
+// WithResourceTemplates registers the resources whose URIs are given by templates.
+func WithResourceTemplates(templates ...resources.Template) ServerOption {
+	return func(s *server.MCPServer) {
+		for _, template := range templates {
+			s.AddResourceTemplate(template.Template(), template.Handle)
+		}
+	}
+}
+
+// WithPrompts registers the prompts.
+func WithPrompts(prompts ...prompts.Prompt) ServerOption {
+	return func(s *server.MCPServer) {
+		for _, prompt := range prompts {
+			s.AddPrompt(prompt.Prompt(), prompt.Handle)
+		}
+	}
+}
+
 func NewServer(
 	version string,
-	tarmaqTool tools.Tool,
+	options ...ServerOption,
 ) *Server {
 	s := server.NewMCPServer(
 		"tarmaq", // Name
@@ -19,7 +59,9 @@ func NewServer(
 		server.WithLogging(),
 	)
 
-	s.AddTool(tarmaqTool.Tool(), tarmaqTool.Handle)
+	for _, option := range options {
+		option(s)
+	}
 
 	return &Server{
 		server: s,
@@ -0,0 +1,140 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+
+	"github.com/mark3labs/mcp-go/mcp"
+
+	"github.com/mazrean/mcp-tarmaq/tarmaq"
+)
+
+var _ Tool = &CoupledFilesTool{}
+
+type CoupledFilesTool struct {
+	repositories *Repositories
+}
+
+func NewCoupledFilesTool(repositories *Repositories) *CoupledFilesTool {
+	return &CoupledFilesTool{
+		repositories: repositories,
+	}
+}
+
+func (h *CoupledFilesTool) Tool() mcp.Tool {
+	return mcp.NewTool("coupled_files",
+		mcp.WithDescription("Profile the historical coupling of a single file in both directions: files that change when it changes, and files whose changes drag it along"),
+		h.repositories.argument(),
+		mcp.WithString("file",
+			mcp.Required(),
+			mcp.Description("file to profile"),
+		),
+		mcp.WithNumber("limit",
+			mcp.Description("maximum number of files in each direction"),
+			mcp.DefaultNumber(20),
+		),
+		mcp.WithNumber("min_support",
+			mcp.Description("minimum number of commits changing both files"),
+			mcp.DefaultNumber(1),
+		),
+	)
+}
+
+type CoupledFilesResponse struct {
+	Path         string                 `json:"file_path"`
+	Changes      uint64                 `json:"changes"`
+	Dependents   []*CoupledFileResponse `json:"changes_with_this_file"`
+	Dependencies []*CoupledFileResponse `json:"drags_this_file_along"`
+}
+
+type CoupledFileResponse struct {
+	Path              string  `json:"file_path"`
+	Support           uint64  `json:"support"`
+	Confidence        float64 `json:"confidence"`
+	ReverseConfidence float64 `json:"reverse_confidence"`
+}
+
+func (h *CoupledFilesTool) Handle(_ context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	file, ok := request.GetArguments()["file"].(string)
+	if !ok {
+		slog.Error("invalid file",
+			slog.String("file", fmt.Sprintf("%v", request.GetArguments()["file"])),
+		)
+		return nil, fmt.Errorf("invalid file: %v", request.GetArguments()["file"])
+	}
+	repo, files, err := h.repositories.Resolve(request.GetArguments(), file)
+	if err != nil {
+		slog.Error("resolve repository",
+			slog.String("error", err.Error()),
+		)
+		return nil, fmt.Errorf("resolve repository: %w", err)
+	}
+	style := repo.Style([]string{file})
+	file = files[0]
+	limit := 20
+	if iLimit, ok := request.GetArguments()["limit"].(float64); ok {
+		limit = int(iLimit)
+	}
+	minSupport := uint64(1)
+	if iMinSupport, ok := request.GetArguments()["min_support"].(float64); ok {
+		minSupport = uint64(iMinSupport)
+	}
+
+	transactions, fileMap, err := repo.Repository.GetTransactions()
+	if err != nil {
+		slog.Error("get transactions",
+			slog.String("error", err.Error()),
+		)
+		return nil, fmt.Errorf("get transactions: %w", err)
+	}
+	transactions = tarmaq.ApplyTxFilters(transactions, nil, repo.TxFilters)
+
+	path := repo.FoldCase([]tarmaq.FilePath{tarmaq.FilePath(file)}, fileMap)[0]
+	coupling, ok := tarmaq.NewFileCoupling(transactions, fileMap, path, minSupport)
+	if !ok {
+		slog.Error("file not found",
+			slog.String("file", file),
+		)
+		return nil, fmt.Errorf("file not found in history: %s", file)
+	}
+
+	response, err := json.MarshalIndent(NewCoupledFilesResponse(coupling, limit, style), "", "  ")
+	if err != nil {
+		slog.Error("marshal response",
+			slog.String("error", err.Error()),
+		)
+		return nil, fmt.Errorf("marshal response: %w", err)
+	}
+
+	return repo.result(string(response)), nil
+}
+
+// NewCoupledFilesResponse creates the response of the coupling of a file, reporting at most limit files in each direction.
+func NewCoupledFilesResponse(coupling *tarmaq.FileCoupling, limit int, style PathStyle) *CoupledFilesResponse {
+	return &CoupledFilesResponse{
+		Path:         style.Format(coupling.Path),
+		Changes:      coupling.Changes,
+		Dependents:   newCoupledFileResponses(coupling.Dependents, limit, style),
+		Dependencies: newCoupledFileResponses(coupling.Dependencies, limit, style),
+	}
+}
+
+func newCoupledFileResponses(files []*tarmaq.CoupledFile, limit int, style PathStyle) []*CoupledFileResponse {
+	if limit > 0 && len(files) > limit {
+		files = files[:limit]
+	}
+
+	res := make([]*CoupledFileResponse, 0, len(files))
+	for _, file := range files {
+		res = append(res, &CoupledFileResponse{
+			Path:              style.Format(file.Path),
+			Support:           file.Support,
+			Confidence:        file.Confidence,
+			ReverseConfidence: file.ReverseConfidence,
+		})
+	}
+
+	return res
+}
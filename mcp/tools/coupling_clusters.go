@@ -0,0 +1,124 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+
+	"github.com/mark3labs/mcp-go/mcp"
+
+	"github.com/mazrean/mcp-tarmaq/tarmaq"
+)
+
+var _ Tool = &CouplingClustersTool{}
+
+type CouplingClustersTool struct {
+	repositories *Repositories
+}
+
+func NewCouplingClustersTool(repositories *Repositories) *CouplingClustersTool {
+	return &CouplingClustersTool{
+		repositories: repositories,
+	}
+}
+
+func (h *CouplingClustersTool) Tool() mcp.Tool {
+	return mcp.NewTool("coupling_clusters",
+		mcp.WithDescription("Detect clusters of files that evolve together in the changelog, regardless of the directory layout"),
+		h.repositories.argument(),
+		mcp.WithString("subtree",
+			mcp.Description("only include files under this directory"),
+		),
+		mcp.WithNumber("min_support",
+			mcp.Description("minimum number of commits changing both files to link them"),
+			mcp.DefaultNumber(2),
+		),
+		mcp.WithNumber("min_strength",
+			mcp.Description("minimum co-change strength (Jaccard index) to link files"),
+			mcp.DefaultNumber(0),
+		),
+		mcp.WithNumber("min_size",
+			mcp.Description("minimum number of files in a reported cluster"),
+			mcp.DefaultNumber(2),
+		),
+	)
+}
+
+type CouplingClustersResponse struct {
+	Modularity float64                    `json:"modularity"`
+	Clusters   []*CouplingClusterResponse `json:"clusters"`
+}
+
+type CouplingClusterResponse struct {
+	Files    []string `json:"files"`
+	Cohesion float64  `json:"cohesion"`
+}
+
+func (h *CouplingClustersTool) Handle(_ context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	options := tarmaq.CouplingOptions{
+		MinSupport: 2,
+	}
+	subtrees := []string{}
+	if subtree, ok := request.GetArguments()["subtree"].(string); ok && subtree != "" {
+		subtrees = append(subtrees, subtree)
+	}
+	repo, relSubtrees, err := h.repositories.Resolve(request.GetArguments(), subtrees...)
+	if err != nil {
+		slog.Error("resolve repository",
+			slog.String("error", err.Error()),
+		)
+		return nil, fmt.Errorf("resolve repository: %w", err)
+	}
+	style := repo.Style(subtrees)
+	subtrees = relSubtrees
+	if len(subtrees) > 0 {
+		options.Subtree = tarmaq.NewFilePath(subtrees[0])
+	}
+	if minSupport, ok := request.GetArguments()["min_support"].(float64); ok {
+		options.MinSupport = uint64(minSupport)
+	}
+	if minStrength, ok := request.GetArguments()["min_strength"].(float64); ok {
+		options.MinStrength = minStrength
+	}
+	minSize := 2
+	if iMinSize, ok := request.GetArguments()["min_size"].(float64); ok {
+		minSize = int(iMinSize)
+	}
+
+	transactions, fileMap, err := repo.Repository.GetTransactions()
+	if err != nil {
+		slog.Error("get transactions",
+			slog.String("error", err.Error()),
+		)
+		return nil, fmt.Errorf("get transactions: %w", err)
+	}
+	transactions = tarmaq.ApplyTxFilters(transactions, nil, repo.TxFilters)
+
+	clustering := tarmaq.DetectClusters(tarmaq.NewCouplingGraph(transactions, fileMap, options), minSize)
+
+	res := &CouplingClustersResponse{
+		Modularity: clustering.Modularity,
+		Clusters:   make([]*CouplingClusterResponse, 0, len(clustering.Clusters)),
+	}
+	for _, cluster := range clustering.Clusters {
+		files := make([]string, 0, len(cluster.Files))
+		for _, file := range cluster.Files {
+			files = append(files, style.Format(file))
+		}
+		res.Clusters = append(res.Clusters, &CouplingClusterResponse{
+			Files:    files,
+			Cohesion: cluster.Cohesion,
+		})
+	}
+
+	response, err := json.MarshalIndent(res, "", "  ")
+	if err != nil {
+		slog.Error("marshal response",
+			slog.String("error", err.Error()),
+		)
+		return nil, fmt.Errorf("marshal response: %w", err)
+	}
+
+	return repo.result(string(response)), nil
+}
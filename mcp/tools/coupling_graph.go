@@ -0,0 +1,99 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/mcp"
+
+	"github.com/mazrean/mcp-tarmaq/tarmaq"
+)
+
+var _ Tool = &CouplingGraphTool{}
+
+type CouplingGraphTool struct {
+	repositories *Repositories
+}
+
+func NewCouplingGraphTool(repositories *Repositories) *CouplingGraphTool {
+	return &CouplingGraphTool{
+		repositories: repositories,
+	}
+}
+
+func (h *CouplingGraphTool) Tool() mcp.Tool {
+	return mcp.NewTool("coupling_graph",
+		mcp.WithDescription("Export the graph of files weighted by how often they change together in the changelog"),
+		h.repositories.argument(),
+		mcp.WithString("format",
+			mcp.Description("output format"),
+			mcp.Enum(string(tarmaq.GraphFormatDOT), string(tarmaq.GraphFormatGraphML), string(tarmaq.GraphFormatJSON)),
+			mcp.DefaultString(string(tarmaq.GraphFormatJSON)),
+		),
+		mcp.WithString("subtree",
+			mcp.Description("only include files under this directory"),
+		),
+		mcp.WithNumber("min_support",
+			mcp.Description("minimum number of commits changing both files"),
+			mcp.DefaultNumber(1),
+		),
+		mcp.WithNumber("min_strength",
+			mcp.Description("minimum co-change strength (Jaccard index) of an edge"),
+			mcp.DefaultNumber(0),
+		),
+	)
+}
+
+func (h *CouplingGraphTool) Handle(_ context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	format := tarmaq.GraphFormatJSON
+	if iFormat, ok := request.GetArguments()["format"].(string); ok {
+		format = tarmaq.GraphFormat(iFormat)
+	}
+
+	options := tarmaq.CouplingOptions{
+		MinSupport: 1,
+	}
+	subtrees := []string{}
+	if subtree, ok := request.GetArguments()["subtree"].(string); ok && subtree != "" {
+		subtrees = append(subtrees, subtree)
+	}
+	repo, subtrees, err := h.repositories.Resolve(request.GetArguments(), subtrees...)
+	if err != nil {
+		slog.Error("resolve repository",
+			slog.String("error", err.Error()),
+		)
+		return nil, fmt.Errorf("resolve repository: %w", err)
+	}
+	if len(subtrees) > 0 {
+		options.Subtree = tarmaq.NewFilePath(subtrees[0])
+	}
+	if minSupport, ok := request.GetArguments()["min_support"].(float64); ok {
+		options.MinSupport = uint64(minSupport)
+	}
+	if minStrength, ok := request.GetArguments()["min_strength"].(float64); ok {
+		options.MinStrength = minStrength
+	}
+
+	transactions, fileMap, err := repo.Repository.GetTransactions()
+	if err != nil {
+		slog.Error("get transactions",
+			slog.String("error", err.Error()),
+		)
+		return nil, fmt.Errorf("get transactions: %w", err)
+	}
+	transactions = tarmaq.ApplyTxFilters(transactions, nil, repo.TxFilters)
+
+	graph := tarmaq.NewCouplingGraph(transactions, fileMap, options)
+
+	var sb strings.Builder
+	if err := graph.Write(&sb, format); err != nil {
+		slog.Error("write graph",
+			slog.String("error", err.Error()),
+		)
+		return nil, fmt.Errorf("write graph: %w", err)
+	}
+
+	return repo.result(sb.String()), nil
+}
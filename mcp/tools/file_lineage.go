@@ -0,0 +1,100 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+
+	"github.com/mazrean/mcp-tarmaq/tarmaq"
+)
+
+var _ Tool = &FileLineageTool{}
+
+type FileLineageTool struct {
+	repositories *Repositories
+}
+
+func NewFileLineageTool(repositories *Repositories) *FileLineageTool {
+	return &FileLineageTool{
+		repositories: repositories,
+	}
+}
+
+func (h *FileLineageTool) Tool() mcp.Tool {
+	return mcp.NewTool("file_lineage",
+		mcp.WithDescription("Show the names a file had across the changelog, following renames and moves"),
+		h.repositories.argument(),
+		mcp.WithString("file",
+			mcp.Required(),
+			mcp.Description("current path of the file"),
+		),
+	)
+}
+
+type FileNameResponse struct {
+	Path      string     `json:"file_path"`
+	RenamedIn string     `json:"renamed_in,omitempty"`
+	RenamedAt *time.Time `json:"renamed_at,omitempty"`
+}
+
+func (h *FileLineageTool) Handle(_ context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	file, ok := request.GetArguments()["file"].(string)
+	if !ok {
+		slog.Error("invalid file",
+			slog.String("file", fmt.Sprintf("%v", request.GetArguments()["file"])),
+		)
+		return nil, fmt.Errorf("invalid file: %v", request.GetArguments()["file"])
+	}
+	repo, files, err := h.repositories.Resolve(request.GetArguments(), file)
+	if err != nil {
+		slog.Error("resolve repository",
+			slog.String("error", err.Error()),
+		)
+		return nil, fmt.Errorf("resolve repository: %w", err)
+	}
+	style := repo.Style([]string{file})
+	file = files[0]
+
+	transactions, fileMap, err := repo.Repository.GetTransactions()
+	if err != nil {
+		slog.Error("get transactions",
+			slog.String("error", err.Error()),
+		)
+		return nil, fmt.Errorf("get transactions: %w", err)
+	}
+
+	path := repo.FoldCase([]tarmaq.FilePath{tarmaq.FilePath(file)}, fileMap)[0]
+	names, ok := tarmaq.Lineage(transactions, fileMap, path)
+	if !ok {
+		slog.Error("file not found",
+			slog.String("file", file),
+		)
+		return nil, fmt.Errorf("file not found in history: %s", file)
+	}
+
+	res := make([]*FileNameResponse, 0, len(names))
+	for _, name := range names {
+		nameRes := &FileNameResponse{
+			Path:      style.Format(name.Path),
+			RenamedIn: name.RenamedIn,
+		}
+		if name.RenamedIn != "" {
+			nameRes.RenamedAt = &name.RenamedAt
+		}
+		res = append(res, nameRes)
+	}
+
+	response, err := json.MarshalIndent(res, "", "  ")
+	if err != nil {
+		slog.Error("marshal response",
+			slog.String("error", err.Error()),
+		)
+		return nil, fmt.Errorf("marshal response: %w", err)
+	}
+
+	return repo.result(string(response)), nil
+}
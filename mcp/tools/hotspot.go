@@ -0,0 +1,121 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+
+	"github.com/mazrean/mcp-tarmaq/tarmaq"
+)
+
+var _ Tool = &HotspotTool{}
+
+type HotspotTool struct {
+	repositories *Repositories
+}
+
+func NewHotspotTool(repositories *Repositories) *HotspotTool {
+	return &HotspotTool{
+		repositories: repositories,
+	}
+}
+
+func (h *HotspotTool) Tool() mcp.Tool {
+	return mcp.NewTool("change_hotspots",
+		mcp.WithDescription("Report the most frequently changed files in the changelog"),
+		h.repositories.argument(),
+		mcp.WithNumber("limit",
+			mcp.Description("maximum number of files to report"),
+			mcp.DefaultNumber(20),
+		),
+		mcp.WithNumber("days",
+			mcp.Description("only consider changes in the last N days (0 means the whole history)"),
+			mcp.DefaultNumber(0),
+		),
+		mcp.WithNumber("half_life_days",
+			mcp.Description("weight changes by recency so that a change N days old counts half (0 disables recency weighting)"),
+			mcp.DefaultNumber(0),
+		),
+		mcp.WithBoolean("weight_by_churn",
+			mcp.Description("weight changes by the number of changed lines, counting every change at least once"),
+			mcp.DefaultBool(false),
+		),
+	)
+}
+
+type HotspotResponse struct {
+	Path        string    `json:"file_path"`
+	Changes     uint64    `json:"changes"`
+	Churn       uint64    `json:"churn"`
+	Score       float64   `json:"score"`
+	LastChanged time.Time `json:"last_changed"`
+}
+
+func (h *HotspotTool) Handle(_ context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	repo, _, err := h.repositories.Resolve(request.GetArguments())
+	if err != nil {
+		slog.Error("resolve repository",
+			slog.String("error", err.Error()),
+		)
+		return nil, fmt.Errorf("resolve repository: %w", err)
+	}
+
+	options := tarmaq.HotspotOptions{
+		Now:   time.Now(),
+		Limit: 20,
+	}
+	if limit, ok := request.GetArguments()["limit"].(float64); ok {
+		options.Limit = int(limit)
+	}
+	if days, ok := request.GetArguments()["days"].(float64); ok {
+		options.Window = time.Duration(days * float64(24*time.Hour))
+	}
+	if halfLife, ok := request.GetArguments()["half_life_days"].(float64); ok {
+		options.HalfLife = time.Duration(halfLife * float64(24*time.Hour))
+	}
+	if churn, ok := request.GetArguments()["weight_by_churn"].(bool); ok {
+		options.ChurnWeighted = churn
+	}
+
+	transactions, fileMap, err := repo.Repository.GetTransactions()
+	if err != nil {
+		slog.Error("get transactions",
+			slog.String("error", err.Error()),
+		)
+		return nil, fmt.Errorf("get transactions: %w", err)
+	}
+
+	transactions = tarmaq.ApplyTxFilters(transactions, nil, repo.TxFilters)
+
+	hotspots := tarmaq.Hotspots(transactions, fileMap, options)
+
+	response, err := json.MarshalIndent(NewHotspotResponses(hotspots, repo.Style(nil)), "", "  ")
+	if err != nil {
+		slog.Error("marshal response",
+			slog.String("error", err.Error()),
+		)
+		return nil, fmt.Errorf("marshal response: %w", err)
+	}
+
+	return repo.result(string(response)), nil
+}
+
+// NewHotspotResponses creates the responses of hotspots with the paths in the style.
+func NewHotspotResponses(hotspots []*tarmaq.Hotspot, style PathStyle) []*HotspotResponse {
+	res := make([]*HotspotResponse, 0, len(hotspots))
+	for _, hotspot := range hotspots {
+		res = append(res, &HotspotResponse{
+			Path:        style.Format(hotspot.Path),
+			Changes:     hotspot.Changes,
+			Churn:       hotspot.Churn,
+			Score:       hotspot.Score,
+			LastChanged: hotspot.LastChanged,
+		})
+	}
+
+	return res
+}
@@ -0,0 +1,119 @@
+package tools
+
+import (
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/mazrean/mcp-tarmaq/tarmaq"
+)
+
+// cleanPath converts a relative path given by a caller to the slash separated form of the history.
+func cleanPath(p string) string {
+	if p == "" {
+		return ""
+	}
+
+	p = path.Clean(strings.ReplaceAll(p, `\`, "/"))
+	if p == "." {
+		return ""
+	}
+
+	return p
+}
+
+// relPath returns path relative to root if it is under root.
+func relPath(root string, path string) (string, bool) {
+	rel, err := filepath.Rel(root, path)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", false
+	}
+
+	return rel, true
+}
+
+// evalSymlinks resolves the symlinks of the longest existing parent of path, since new files do not exist yet.
+func evalSymlinks(path string) string {
+	dir, rest := filepath.Clean(path), ""
+	for {
+		if resolved, err := filepath.EvalSymlinks(dir); err == nil {
+			return filepath.Join(resolved, rest)
+		}
+
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return path
+		}
+		rest = filepath.Join(filepath.Base(dir), rest)
+		dir = parent
+	}
+}
+
+// PathStyle is the style of a path given by a caller, which paths in responses are mapped back to.
+type PathStyle struct {
+	// root is the root of the repository as written by the caller if the path is absolute.
+	root string
+	// backslash is true if the path is separated by backslashes.
+	backslash bool
+	// dotSlash is true if the path starts with ./
+	dotSlash bool
+}
+
+// Style returns the style of the first of the paths given by a caller.
+func (r *Repository) Style(paths []string) PathStyle {
+	if len(paths) == 0 {
+		return PathStyle{}
+	}
+	p := paths[0]
+
+	if filepath.IsAbs(p) && len(r.Members) == 0 {
+		rel, ok := r.rel(p)
+		if !ok {
+			return PathStyle{}
+		}
+		// keep the root as written by the caller, e.g. through a symlink
+		p = filepath.Clean(p)
+		root := r.Root
+		if rel == "." {
+			root = p
+		} else if prefix, ok := strings.CutSuffix(p, string(filepath.Separator)+rel); ok {
+			root = prefix
+		}
+
+		return PathStyle{root: root}
+	}
+
+	return PathStyle{
+		backslash: os.PathSeparator != '\\' && strings.Contains(p, `\`),
+		dotSlash:  strings.HasPrefix(p, "./") || strings.HasPrefix(p, `.\`),
+	}
+}
+
+// Format maps a path of the history to the style.
+func (s PathStyle) Format(path tarmaq.FilePath) string {
+	p := filepath.FromSlash(string(path))
+	switch {
+	case s.root != "":
+		return filepath.Join(s.root, p)
+	case s.backslash:
+		p = strings.ReplaceAll(p, "/", `\`)
+		if s.dotSlash {
+			p = `.\` + p
+		}
+	case s.dotSlash:
+		p = "." + string(filepath.Separator) + p
+	}
+
+	return p
+}
+
+// FoldCase matches the paths not found in the history to the paths differing only in case if IgnoreCase is set.
+func (r *Repository) FoldCase(paths []tarmaq.FilePath, fileMap map[tarmaq.FileID]tarmaq.FilePath) []tarmaq.FilePath {
+	if !r.IgnoreCase {
+		return paths
+	}
+	paths, _ = tarmaq.FoldCase(paths, fileMap)
+
+	return paths
+}
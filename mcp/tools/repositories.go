@@ -0,0 +1,197 @@
+package tools
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/mcp"
+
+	"github.com/mazrean/mcp-tarmaq/tarmaq"
+)
+
+// Repository is a repository served by the tools.
+type Repository struct {
+	Name string
+	// Root is the absolute path of the working tree.
+	Root       string
+	Repository tarmaq.Repository
+	// TxFilters are the filters that do not depend on the query.
+	TxFilters []tarmaq.TxFilter
+	Tarmaq    *tarmaq.Tarmaq
+	// Members are the repositories joined by a tarmaq.CrossRepository.
+	// Root is empty for such a repository.
+	Members []*Repository
+	// ShallowDepth is the number of commits mined from a shallow clone, or 0 if the history is complete.
+	ShallowDepth int
+	// IgnoreCase matches query files to the history ignoring case.
+	IgnoreCase bool
+	// UserTxFilter keeps the history of the user, or is nil if the user is unknown.
+	UserTxFilter *tarmaq.AuthorTxFilter
+}
+
+type Repositories struct {
+	repositories []*Repository
+}
+
+func NewRepositories(repositories ...*Repository) *Repositories {
+	return &Repositories{
+		repositories: repositories,
+	}
+}
+
+func (r *Repositories) Names() []string {
+	names := make([]string, 0, len(r.repositories))
+	for _, repo := range r.repositories {
+		names = append(names, repo.Name)
+	}
+
+	return names
+}
+
+// All returns all repositories.
+func (r *Repositories) All() []*Repository {
+	return r.repositories
+}
+
+// argument declares the repository argument of a tool.
+func (r *Repositories) argument() mcp.ToolOption {
+	return mcp.WithString("repository",
+		mcp.Description(fmt.Sprintf(
+			"name of the repository (%s). It can be omitted if there is only one repository or the file paths are absolute",
+			strings.Join(r.Names(), ", "),
+		)),
+	)
+}
+
+// Resolve selects the repository named by the repository argument.
+// Without the argument, the repository is inferred from absolute paths, or is the only one served.
+// Absolute paths are returned relative to the root of the selected repository,
+// or as tarmaq.CrossRepositoryPath for a cross repository.
+func (r *Repositories) Resolve(arguments map[string]any, paths ...string) (*Repository, []string, error) {
+	if name, ok := arguments["repository"].(string); ok && name != "" {
+		for _, repo := range r.repositories {
+			if repo.Name == name {
+				return repo, repo.relativePaths(paths), nil
+			}
+		}
+
+		return nil, nil, fmt.Errorf("unknown repository: %s (available: %s)", name, strings.Join(r.Names(), ", "))
+	}
+
+	// candidates are the repositories containing all absolute paths
+	var candidates []*Repository
+	for _, repo := range r.repositories {
+		contained := false
+		for _, path := range paths {
+			if !filepath.IsAbs(path) {
+				continue
+			}
+			contained = repo.contains(path)
+			if !contained {
+				break
+			}
+		}
+		if contained {
+			candidates = append(candidates, repo)
+		}
+	}
+	if len(candidates) > 0 {
+		// prefer a single repository over a cross repository,
+		// and a submodule or subdirectory over the repository containing it
+		slices.SortStableFunc(candidates, func(a, b *Repository) int {
+			if len(a.Members) != len(b.Members) {
+				return len(a.Members) - len(b.Members)
+			}
+			return len(b.Root) - len(a.Root)
+		})
+		return candidates[0], candidates[0].relativePaths(paths), nil
+	}
+
+	switch len(r.repositories) {
+	case 0:
+		return nil, nil, errors.New("no repository is served")
+	case 1:
+		return r.repositories[0], r.repositories[0].relativePaths(paths), nil
+	default:
+		return nil, nil, fmt.Errorf("repository is required (available: %s)", strings.Join(r.Names(), ", "))
+	}
+}
+
+func (r *Repository) contains(path string) bool {
+	if len(r.Members) > 0 {
+		return slices.ContainsFunc(r.Members, func(member *Repository) bool {
+			return member.contains(path)
+		})
+	}
+
+	_, ok := r.rel(path)
+	return ok
+}
+
+// rel returns the absolute path relative to the root, following symlinks in both.
+func (r *Repository) rel(path string) (string, bool) {
+	if r.Root == "" || !filepath.IsAbs(path) {
+		return "", false
+	}
+	if rel, ok := relPath(r.Root, path); ok {
+		return rel, true
+	}
+
+	root, err := filepath.EvalSymlinks(r.Root)
+	if err != nil {
+		return "", false
+	}
+	return relPath(root, evalSymlinks(path))
+}
+
+// relativePaths normalizes the paths given by a caller to the slash separated paths of the history.
+// Absolute paths are made relative to the root, and backslashes, ./ and .. are resolved.
+func (r *Repository) relativePaths(paths []string) []string {
+	relPaths := make([]string, 0, len(paths))
+	for _, path := range paths {
+		if filepath.IsAbs(path) && len(r.Members) > 0 {
+			for _, member := range r.Members {
+				if member.contains(path) {
+					path = string(tarmaq.CrossRepositoryPath(member.Name, tarmaq.FilePath(member.relativePaths([]string{path})[0])))
+					break
+				}
+			}
+		} else if !filepath.IsAbs(path) {
+			path = cleanPath(path)
+		} else if rel, ok := r.rel(path); ok {
+			path = cleanPath(rel)
+		}
+		relPaths = append(relPaths, path)
+	}
+
+	return relPaths
+}
+
+// Notices returns the notices on the history of the repository and its members.
+func (r *Repository) Notices() []string {
+	var notices []string
+	if r.ShallowDepth > 0 {
+		notices = append(notices, fmt.Sprintf(
+			"repository %s is a shallow clone: only the latest %d commits are mined, so coupling with older changes is missing",
+			r.Name, r.ShallowDepth,
+		))
+	}
+	for _, member := range r.Members {
+		notices = append(notices, member.Notices()...)
+	}
+
+	return notices
+}
+
+// result creates the result of a tool, followed by the notices on the history of the repository.
+func (r *Repository) result(text string) *mcp.CallToolResult {
+	result := mcp.NewToolResultText(text)
+	for _, notice := range r.Notices() {
+		result.Content = append(result.Content, mcp.NewTextContent(notice))
+	}
+
+	return result
+}
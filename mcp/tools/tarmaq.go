@@ -3,9 +3,11 @@ package tools
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log/slog"
-	"path/filepath"
+	"slices"
+	"strings"
 
 	"github.com/mark3labs/mcp-go/mcp"
 
@@ -15,41 +17,97 @@ import (
 var _ Tool = &TarmaqTool{}
 
 type TarmaqTool struct {
-	executer *tarmaq.Tarmaq
+	repositories *Repositories
 }
 
-func NewTarmaqTool(executer *tarmaq.Tarmaq) *TarmaqTool {
+func NewTarmaqTool(repositories *Repositories) *TarmaqTool {
 	return &TarmaqTool{
-		executer: executer,
+		repositories: repositories,
 	}
 }
 
 func (h *TarmaqTool) Tool() mcp.Tool {
 	return mcp.NewTool("impact_analysis",
 		mcp.WithDescription("Suggest files that are likely to change at the same time in the changelog"),
+		h.repositories.argument(),
 		mcp.WithArray("files",
 			mcp.Required(),
 			mcp.Description("already modified files"),
 		),
+		mcp.WithArray("refs",
+			mcp.Description("refs to mine the history from (e.g. origin/main) instead of the configured ones"),
+		),
+		mcp.WithBoolean("all_refs",
+			mcp.Description("mine the history of all branches"),
+			mcp.DefaultBool(false),
+		),
+		mcp.WithString("history",
+			mcp.Description("mine the history of the whole team, or only the commits authored or committed by the user (mine), whose companion changes can differ from those of other teams"),
+			mcp.Enum("team", "mine"),
+			mcp.DefaultString("team"),
+		),
+		mcp.WithBoolean("exclude_deleted",
+			mcp.Description("drop suggested files that no longer exist in the repository"),
+			mcp.DefaultBool(false),
+		),
+		mcp.WithOutputSchema[ImpactAnalysisResponse](),
 	)
 }
 
+type ImpactAnalysisResponse struct {
+	Files       []*TarmaqResponse    `json:"files" jsonschema_description:"files likely to change together with the modified files, in descending order of confidence"`
+	Tests       []*TestResponse      `json:"tests" jsonschema_description:"tests likely to need updates, paired with the modified files by their names and history"`
+	Warnings    []string             `json:"warnings,omitempty" jsonschema_description:"warnings about the query and the mined history"`
+	Diagnostics *DiagnosticsResponse `json:"diagnostics" jsonschema_description:"how the modified files were interpreted and how many commits were mined"`
+}
+
+type DiagnosticsResponse struct {
+	MatchedFiles []string                  `json:"matched_files" jsonschema_description:"modified files found in the history"`
+	UnknownFiles []string                  `json:"unknown_files" jsonschema_description:"modified files not found in the history, which are ignored"`
+	Normalized   []*NormalizedPathResponse `json:"normalized,omitempty" jsonschema_description:"modified files whose paths were rewritten to the slash separated paths of the history before the lookup"`
+	Transactions int                       `json:"transactions" jsonschema_description:"number of commits in the mined history"`
+	TxFilters    []*TxFilterResponse       `json:"tx_filters" jsonschema_description:"number of commits surviving each filter, in the order of application"`
+}
+
+type NormalizedPathResponse struct {
+	Input string `json:"input"`
+	Path  string `json:"path"`
+}
+
+type TxFilterResponse struct {
+	Filter       string `json:"filter"`
+	Transactions int    `json:"transactions"`
+}
+
 type TarmaqResponse struct {
-	Path       string  `json:"file_path"`
-	Confidence float64 `json:"confidence"`
-	Support    uint64  `json:"support"`
+	Path        string   `json:"file_path" jsonschema_description:"file likely to change, in the path style of the modified files"`
+	Confidence  float64  `json:"confidence" jsonschema_description:"ratio of the commits changing the antecedents which also changed the file"`
+	Support     uint64   `json:"support" jsonschema_description:"number of commits changing both the antecedents and the file"`
+	Deleted     bool     `json:"deleted,omitempty" jsonschema_description:"the file no longer exists"`
+	Antecedents []string `json:"antecedents" jsonschema_description:"modified files the suggestion is based on"`
+	Rationale   string   `json:"rationale" jsonschema_description:"human readable reason of the suggestion"`
+	Fallback    string   `json:"fallback,omitempty" jsonschema:"enum=same_name,enum=sibling,enum=directory" jsonschema_description:"heuristic the suggestion is derived from when none of the modified files have history. Such suggestions have lower evidence"`
+}
+
+type TestResponse struct {
+	Path       string  `json:"file_path" jsonschema_description:"test likely to need updates, in the path style of the modified files"`
+	TestedFile string  `json:"tested_file" jsonschema_description:"modified file the test is paired with"`
+	NameMatch  bool    `json:"name_match" jsonschema_description:"the test is named after the modified file, e.g. foo_test.go for foo.go"`
+	Support    uint64  `json:"support" jsonschema_description:"number of commits changing both the modified file and the test"`
+	Confidence float64 `json:"confidence" jsonschema_description:"ratio of the commits changing the modified file which also changed the test"`
+	Rationale  string  `json:"rationale" jsonschema_description:"human readable reason of the pairing"`
 }
 
 func (h *TarmaqTool) Handle(_ context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
-	iFiles, ok := request.Params.Arguments["files"].([]any)
+	iFiles, ok := request.GetArguments()["files"].([]any)
 	if !ok {
 		slog.Error("invalid files",
-			slog.String("files", fmt.Sprintf("%v", request.Params.Arguments["files"])),
+			slog.String("files", fmt.Sprintf("%v", request.GetArguments()["files"])),
 		)
-		return nil, fmt.Errorf("invalid files: %v", request.Params.Arguments["files"])
+		return nil, fmt.Errorf("invalid files: %v", request.GetArguments()["files"])
 	}
 
-	tarmaqFiles := make([]tarmaq.FilePath, 0, len(iFiles))
+	files := make([]string, 0, len(iFiles))
 	for _, iFile := range iFiles {
 		file, ok := iFile.(string)
 		if !ok {
@@ -58,9 +116,71 @@ func (h *TarmaqTool) Handle(_ context.Context, request mcp.CallToolRequest) (*mc
 			)
 			continue
 		}
+		files = append(files, file)
+	}
+
+	repo, relFiles, err := h.repositories.Resolve(request.GetArguments(), files...)
+	if err != nil {
+		slog.Error("resolve repository",
+			slog.String("error", err.Error()),
+		)
+		return nil, fmt.Errorf("resolve repository: %w", err)
+	}
+
+	style := repo.Style(files)
+	var normalized []*NormalizedPathResponse
+	tarmaqFiles := make([]tarmaq.FilePath, 0, len(relFiles))
+	for i, file := range relFiles {
+		if file != files[i] {
+			normalized = append(normalized, &NormalizedPathResponse{
+				Input: files[i],
+				Path:  file,
+			})
+		}
 		tarmaqFiles = append(tarmaqFiles, tarmaq.FilePath(file))
 	}
-	result, err := h.executer.Execute(tarmaqFiles)
+
+	var options []tarmaq.ExecuteOption
+	if excludeDeleted, ok := request.GetArguments()["exclude_deleted"].(bool); ok && excludeDeleted {
+		options = append(options, tarmaq.ExcludeDeleted())
+	}
+	if iRefs, ok := request.GetArguments()["refs"].([]any); ok && len(iRefs) > 0 {
+		refs := make([]string, 0, len(iRefs))
+		for _, iRef := range iRefs {
+			ref, ok := iRef.(string)
+			if !ok {
+				slog.Warn("invalid ref",
+					slog.String("ref", fmt.Sprintf("%v", iRef)),
+				)
+				continue
+			}
+			refs = append(refs, ref)
+		}
+		options = append(options, tarmaq.OnRefs(refs...))
+	}
+	if allRefs, ok := request.GetArguments()["all_refs"].(bool); ok && allRefs {
+		options = append(options, tarmaq.OnAllRefs())
+	}
+	if repo.IgnoreCase {
+		options = append(options, tarmaq.IgnoreCase())
+	}
+	var txFilters []tarmaq.TxFilter
+	if history, ok := request.GetArguments()["history"].(string); ok && history == "mine" {
+		if repo.UserTxFilter == nil {
+			slog.Error("unknown user",
+				slog.String("repository", repo.Name),
+			)
+			return nil, errors.New("the user is unknown, so the history of the user cannot be mined: configure user.email of git or --author")
+		}
+		txFilters = append(txFilters, repo.UserTxFilter)
+		options = append(options, tarmaq.WithTxFilters(txFilters...))
+	}
+
+	var diagnostics tarmaq.Diagnostics
+	var history tarmaq.History
+	options = append(options, tarmaq.WithDiagnostics(&diagnostics), tarmaq.WithHistory(&history))
+
+	result, err := repo.Tarmaq.Execute(tarmaqFiles, options...)
 	if err != nil {
 		slog.Error("execute tarmaq",
 			slog.String("error", err.Error()),
@@ -68,14 +188,82 @@ func (h *TarmaqTool) Handle(_ context.Context, request mcp.CallToolRequest) (*mc
 		return nil, fmt.Errorf("execute tarmaq: %w", err)
 	}
 
-	res := make([]*TarmaqResponse, 0, len(result))
+	res := &ImpactAnalysisResponse{
+		Files:    make([]*TarmaqResponse, 0, len(result)),
+		Tests:    []*TestResponse{},
+		Warnings: repo.Notices(),
+		Diagnostics: &DiagnosticsResponse{
+			MatchedFiles: make([]string, 0, len(diagnostics.MatchedFiles)),
+			UnknownFiles: make([]string, 0, len(diagnostics.UnknownFiles)),
+			Normalized:   normalized,
+			Transactions: diagnostics.Transactions,
+			TxFilters:    make([]*TxFilterResponse, 0, len(diagnostics.TxFilters)),
+		},
+	}
 	for _, rule := range result {
-		res = append(res, &TarmaqResponse{
-			Path:       filepath.FromSlash(string(rule.Path)),
-			Confidence: rule.Confidence,
-			Support:    rule.Support,
+		antecedents := make([]string, 0, len(rule.Antecedents))
+		for _, antecedent := range rule.Antecedents {
+			antecedents = append(antecedents, style.Format(antecedent))
+		}
+		res.Files = append(res.Files, &TarmaqResponse{
+			Path:        style.Format(rule.Path),
+			Confidence:  rule.Confidence,
+			Support:     rule.Support,
+			Deleted:     rule.Deleted,
+			Antecedents: antecedents,
+			Rationale: fmt.Sprintf("changed in %d of the past commits changing %s (%.0f%%)",
+				rule.Support, strings.Join(antecedents, ", "), rule.Confidence*100),
+		})
+	}
+	for _, file := range diagnostics.MatchedFiles {
+		res.Diagnostics.MatchedFiles = append(res.Diagnostics.MatchedFiles, style.Format(file))
+	}
+	for _, file := range diagnostics.UnknownFiles {
+		res.Diagnostics.UnknownFiles = append(res.Diagnostics.UnknownFiles, style.Format(file))
+	}
+	for _, match := range diagnostics.CaseFolded {
+		res.Diagnostics.Normalized = append(res.Diagnostics.Normalized, &NormalizedPathResponse{
+			Input: string(match.Query),
+			Path:  string(match.Path),
+		})
+	}
+	for _, count := range diagnostics.TxFilters {
+		res.Diagnostics.TxFilters = append(res.Diagnostics.TxFilters, &TxFilterResponse{
+			Filter:       count.Filter,
+			Transactions: count.Transactions,
 		})
 	}
+	if len(diagnostics.UnknownFiles) > 0 {
+		res.Warnings = append(res.Warnings, fmt.Sprintf(
+			"%d of the %d modified files are not found in the history and are ignored: new files, files outside of the repository and misspelled paths have no history",
+			len(diagnostics.UnknownFiles), len(files),
+		))
+	}
+	if len(diagnostics.MatchedFiles) > 0 && len(diagnostics.TxFilters) > 0 && diagnostics.TxFilters[len(diagnostics.TxFilters)-1].Transactions == 0 {
+		res.Warnings = append(res.Warnings, "no commits survived the filters, so there are no suggestions")
+	}
+
+	// fallbacks and test pairs are based on the same history as the rules, without the query dependent filters
+	transactions := tarmaq.ApplyTxFilters(history.Transactions, nil, slices.Concat(txFilters, repo.TxFilters))
+	fileMap := history.FileMap
+
+	queried := make([]tarmaq.FilePath, 0, len(diagnostics.MatchedFiles)+len(diagnostics.UnknownFiles))
+	queried = append(queried, diagnostics.MatchedFiles...)
+	queried = append(queried, diagnostics.UnknownFiles...)
+	for _, pair := range tarmaq.TestPairs(transactions, fileMap, queried, testLimit) {
+		res.Tests = append(res.Tests, newTestResponse(pair, style))
+	}
+
+	if len(diagnostics.MatchedFiles) == 0 && len(diagnostics.UnknownFiles) > 0 {
+		// brand-new files still get guidance from their names and directories
+		fallbacks := tarmaq.Fallbacks(transactions, fileMap, diagnostics.UnknownFiles, fallbackLimit)
+		for _, fallback := range fallbacks {
+			res.Files = append(res.Files, newFallbackResponse(fallback, style))
+		}
+		if len(fallbacks) > 0 {
+			res.Warnings = append(res.Warnings, "none of the modified files have history, so the files are fallback suggestions with lower evidence, derived from their names and directories")
+		}
+	}
 
 	response, err := json.MarshalIndent(res, "", "  ")
 	if err != nil {
@@ -85,5 +273,58 @@ func (h *TarmaqTool) Handle(_ context.Context, request mcp.CallToolRequest) (*mc
 		return nil, fmt.Errorf("marshal response: %w", err)
 	}
 
-	return mcp.NewToolResultText(string(response)), nil
+	return mcp.NewToolResultStructured(res, string(response)), nil
+}
+
+const (
+	// fallbackLimit is the maximum number of fallback suggestions.
+	fallbackLimit = 20
+	// testLimit is the maximum number of tests likely to need updates.
+	testLimit = 20
+)
+
+func newTestResponse(pair *tarmaq.TestPair, style PathStyle) *TestResponse {
+	res := &TestResponse{
+		Path:       style.Format(pair.Path),
+		TestedFile: style.Format(pair.File),
+		NameMatch:  pair.NameMatch,
+		Support:    pair.Support,
+		Confidence: pair.Confidence,
+	}
+
+	switch {
+	case pair.NameMatch && pair.Support > 0:
+		res.Rationale = fmt.Sprintf("named after %s, and changed in %d of the past commits changing it (%.0f%%)",
+			res.TestedFile, pair.Support, pair.Confidence*100)
+	case pair.NameMatch:
+		res.Rationale = fmt.Sprintf("named after %s", res.TestedFile)
+	default:
+		res.Rationale = fmt.Sprintf("changed in %d of the past commits changing %s (%.0f%%)",
+			pair.Support, res.TestedFile, pair.Confidence*100)
+	}
+
+	return res
+}
+
+func newFallbackResponse(fallback *tarmaq.Fallback, style PathStyle) *TarmaqResponse {
+	res := &TarmaqResponse{
+		Path:        style.Format(fallback.Path),
+		Confidence:  fallback.Confidence,
+		Support:     fallback.Support,
+		Antecedents: []string{},
+		Fallback:    string(fallback.Source),
+	}
+
+	switch fallback.Source {
+	case tarmaq.FallbackSameName:
+		res.Rationale = "has the same base name as a modified file without history in the same or a mirrored test directory (no history, lowest evidence)"
+	case tarmaq.FallbackSibling:
+		res.Rationale = fmt.Sprintf("changed in %d of the past commits changing files in %s, the directory of a modified file without history (%.0f%%, lower evidence)",
+			fallback.Support, style.Format(fallback.Dir), fallback.Confidence*100)
+	case tarmaq.FallbackDirectory:
+		res.Rationale = fmt.Sprintf("changed in %d of the past commits changing files in %s, the directory of a modified file without history, from another directory (%.0f%%, lower evidence)",
+			fallback.Support, style.Format(fallback.Dir), fallback.Confidence*100)
+	}
+
+	return res
 }
@@ -0,0 +1,245 @@
+package tarmaq
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/mazrean/mcp-tarmaq/pkg/collection"
+)
+
+var _ Repository = &ChangeLogRepository{}
+
+// ChangeLogFormat is the format of a change log file.
+type ChangeLogFormat string
+
+const (
+	// ChangeLogFormatJSONL is a JSON object per line with the keys of ChangeSet.
+	ChangeLogFormatJSONL ChangeLogFormat = "jsonl"
+	// ChangeLogFormatJSON is a JSON array of objects with the keys of ChangeSet.
+	ChangeLogFormatJSON ChangeLogFormat = "json"
+	// ChangeLogFormatCSV is a header row followed by a row per change set.
+	// The columns are id, timestamp, author, message and paths, which are separated by ";".
+	// The author and message columns are optional.
+	ChangeLogFormatCSV ChangeLogFormat = "csv"
+)
+
+// ChangeSet is a change set of a change log.
+type ChangeSet struct {
+	ID string `json:"id"`
+	// Timestamp is an RFC 3339 time or Unix time in seconds.
+	Timestamp string   `json:"timestamp"`
+	Author    string   `json:"author"`
+	Message   string   `json:"message"`
+	Paths     []string `json:"paths"`
+}
+
+// ChangeLogRepository reads transactions from a change log file exported from another version control system.
+type ChangeLogRepository struct {
+	path             string
+	format           ChangeLogFormat
+	transactionLimit int
+}
+
+// NewChangeLogRepository reads the change log at path. The format is detected by the extension.
+func NewChangeLogRepository(path string, transactionLimit int) (*ChangeLogRepository, error) {
+	var format ChangeLogFormat
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".jsonl", ".ndjson":
+		format = ChangeLogFormatJSONL
+	case ".json":
+		format = ChangeLogFormatJSON
+	case ".csv":
+		format = ChangeLogFormatCSV
+	default:
+		return nil, fmt.Errorf("unknown change log format: %s (expected .jsonl, .ndjson, .json or .csv)", path)
+	}
+
+	if _, err := os.Stat(path); err != nil {
+		return nil, fmt.Errorf("stat change log: %w", err)
+	}
+
+	return &ChangeLogRepository{
+		path:             path,
+		format:           format,
+		transactionLimit: transactionLimit,
+	}, nil
+}
+
+func (r *ChangeLogRepository) GetTransactions() ([]*Transaction, map[FileID]FilePath, error) {
+	f, err := os.Open(r.path)
+	if err != nil {
+		return nil, nil, fmt.Errorf("open change log: %w", err)
+	}
+	defer f.Close()
+
+	var changeSets []*ChangeSet
+	switch r.format {
+	case ChangeLogFormatCSV:
+		changeSets, err = readChangeLogCSV(f)
+	case ChangeLogFormatJSON:
+		changeSets, err = readChangeLogJSON(f)
+	default:
+		changeSets, err = readChangeLogJSONL(f)
+	}
+	if err != nil {
+		return nil, nil, fmt.Errorf("read change log: %w", err)
+	}
+
+	return changeSetTransactions(changeSets, r.transactionLimit)
+}
+
+func readChangeLogJSONL(r io.Reader) ([]*ChangeSet, error) {
+	var changeSets []*ChangeSet
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(nil, 16*1024*1024)
+	for line := 1; scanner.Scan(); line++ {
+		if strings.TrimSpace(scanner.Text()) == "" {
+			continue
+		}
+
+		var changeSet ChangeSet
+		if err := json.Unmarshal(scanner.Bytes(), &changeSet); err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+		changeSets = append(changeSets, &changeSet)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return changeSets, nil
+}
+
+func readChangeLogJSON(r io.Reader) ([]*ChangeSet, error) {
+	var changeSets []*ChangeSet
+	if err := json.NewDecoder(r).Decode(&changeSets); err != nil {
+		return nil, fmt.Errorf("decode array of change sets: %w", err)
+	}
+
+	return changeSets, nil
+}
+
+func readChangeLogCSV(r io.Reader) ([]*ChangeSet, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+
+	header, err := reader.Read()
+	if errors.Is(err, io.EOF) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("read header: %w", err)
+	}
+
+	columns := make(map[string]int, len(header))
+	for i, name := range header {
+		columns[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+	for _, name := range []string{"id", "timestamp", "paths"} {
+		if _, ok := columns[name]; !ok {
+			return nil, fmt.Errorf("missing column: %s", name)
+		}
+	}
+	column := func(record []string, name string) string {
+		i, ok := columns[name]
+		if !ok || i >= len(record) {
+			return ""
+		}
+		return record[i]
+	}
+
+	var changeSets []*ChangeSet
+	for {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		var paths []string
+		for _, path := range strings.Split(column(record, "paths"), ";") {
+			if path = strings.TrimSpace(path); path != "" {
+				paths = append(paths, path)
+			}
+		}
+
+		changeSets = append(changeSets, &ChangeSet{
+			ID:        column(record, "id"),
+			Timestamp: column(record, "timestamp"),
+			Author:    column(record, "author"),
+			Message:   column(record, "message"),
+			Paths:     paths,
+		})
+	}
+
+	return changeSets, nil
+}
+
+func parseChangeSetTime(timestamp string) (time.Time, error) {
+	if seconds, err := strconv.ParseInt(timestamp, 10, 64); err == nil {
+		return time.Unix(seconds, 0), nil
+	}
+
+	return time.Parse(time.RFC3339, timestamp)
+}
+
+// changeSetTransactions converts change sets to transactions ordered from the newest.
+func changeSetTransactions(changeSets []*ChangeSet, transactionLimit int) ([]*Transaction, map[FileID]FilePath, error) {
+	idGenerator := FileIDGenerator{0}
+	fileMap := make(map[FileID]FilePath)
+	fileIDMap := make(map[string]FileID)
+
+	transactions := make([]*Transaction, 0, len(changeSets))
+	for _, changeSet := range changeSets {
+		t, err := parseChangeSetTime(changeSet.Timestamp)
+		if err != nil {
+			return nil, nil, fmt.Errorf("parse timestamp of %s: %w", changeSet.ID, err)
+		}
+
+		files := collection.NewSet[FileID]()
+		for _, path := range changeSet.Paths {
+			path = filepath.ToSlash(path)
+
+			fileID, ok := fileIDMap[path]
+			if !ok {
+				fileID = idGenerator.Next()
+				fileMap[fileID] = NewFilePath(path)
+				fileIDMap[path] = fileID
+			}
+			files.Add(fileID)
+		}
+		if files.Len() == 0 {
+			continue
+		}
+
+		transactions = append(transactions, &Transaction{
+			ID:      changeSet.ID,
+			Time:    t,
+			Author:  changeSet.Author,
+			Message: changeSet.Message,
+			Files:   files,
+		})
+	}
+
+	slices.SortStableFunc(transactions, func(a, b *Transaction) int {
+		return b.Time.Compare(a.Time)
+	})
+	if transactionLimit != 0 && len(transactions) > transactionLimit {
+		transactions = transactions[:transactionLimit]
+	}
+
+	return transactions, fileMap, nil
+}
@@ -0,0 +1,186 @@
+package tarmaq
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestChangeLogRepository_GetTransactions(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name             string
+		fileName         string
+		content          string
+		transactionLimit int
+		wantTrans        []*Transaction
+		wantFileMap      map[FileID]FilePath
+		wantErr          bool
+	}{
+		{
+			name:     "JSONL",
+			fileName: "changes.jsonl",
+			content: `{"id":"1","timestamp":"2024-01-01T00:00:00Z","author":"alice@example.com","paths":["a.go","b.go"]}
+
+{"id":"2","timestamp":"1704153600","author":"bob@example.com","message":"Update a.go","paths":["a.go"]}
+`,
+			wantTrans: []*Transaction{
+				{
+					ID:      "2",
+					Time:    time.Unix(1704153600, 0),
+					Author:  "bob@example.com",
+					Message: "Update a.go",
+					Files:   makeFileSet(FileID(0)),
+				},
+				{
+					ID:     "1",
+					Time:   time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+					Author: "alice@example.com",
+					Files:  makeFileSet(FileID(0), FileID(1)),
+				},
+			},
+			wantFileMap: map[FileID]FilePath{
+				FileID(0): NewFilePath("a.go"),
+				FileID(1): NewFilePath("b.go"),
+			},
+		},
+		{
+			name:     "JSON",
+			fileName: "changes.json",
+			content: `[
+  {"id":"1","timestamp":"2024-01-01T00:00:00Z","author":"alice@example.com","paths":["a.go","b.go"]},
+  {"id":"2","timestamp":"1704153600","author":"bob@example.com","message":"Update a.go","paths":["a.go"]}
+]
+`,
+			wantTrans: []*Transaction{
+				{
+					ID:      "2",
+					Time:    time.Unix(1704153600, 0),
+					Author:  "bob@example.com",
+					Message: "Update a.go",
+					Files:   makeFileSet(FileID(0)),
+				},
+				{
+					ID:     "1",
+					Time:   time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+					Author: "alice@example.com",
+					Files:  makeFileSet(FileID(0), FileID(1)),
+				},
+			},
+			wantFileMap: map[FileID]FilePath{
+				FileID(0): NewFilePath("a.go"),
+				FileID(1): NewFilePath("b.go"),
+			},
+		},
+		{
+			name:     "JSON lines in a .json file",
+			fileName: "changes.json",
+			content:  `{"id":"1","timestamp":"2024-01-01T00:00:00Z","paths":["a.go"]}`,
+			wantErr:  true,
+		},
+		{
+			name:     "CSV",
+			fileName: "changes.csv",
+			content: `id,timestamp,author,paths
+1,2024-01-01T00:00:00Z,alice@example.com,a.go;b.go
+2,2024-01-02T00:00:00Z,bob@example.com,"a.go; c.go"
+3,2024-01-03T00:00:00Z,bob@example.com,
+`,
+			wantTrans: []*Transaction{
+				{
+					ID:     "2",
+					Time:   time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC),
+					Author: "bob@example.com",
+					Files:  makeFileSet(FileID(0), FileID(2)),
+				},
+				{
+					ID:     "1",
+					Time:   time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+					Author: "alice@example.com",
+					Files:  makeFileSet(FileID(0), FileID(1)),
+				},
+			},
+			wantFileMap: map[FileID]FilePath{
+				FileID(0): NewFilePath("a.go"),
+				FileID(1): NewFilePath("b.go"),
+				FileID(2): NewFilePath("c.go"),
+			},
+		},
+		{
+			name:     "Transaction limit",
+			fileName: "changes.csv",
+			content: `id,timestamp,paths
+1,2024-01-01T00:00:00Z,a.go
+2,2024-01-02T00:00:00Z,b.go
+`,
+			transactionLimit: 1,
+			wantTrans: []*Transaction{
+				{
+					ID:    "2",
+					Time:  time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC),
+					Files: makeFileSet(FileID(1)),
+				},
+			},
+			wantFileMap: map[FileID]FilePath{
+				FileID(0): NewFilePath("a.go"),
+				FileID(1): NewFilePath("b.go"),
+			},
+		},
+		{
+			name:     "Missing column",
+			fileName: "changes.csv",
+			content: `id,paths
+1,a.go
+`,
+			wantErr: true,
+		},
+		{
+			name:     "Invalid timestamp",
+			fileName: "changes.jsonl",
+			content:  `{"id":"1","timestamp":"yesterday","paths":["a.go"]}`,
+			wantErr:  true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			path := filepath.Join(t.TempDir(), tt.fileName)
+			if err := os.WriteFile(path, []byte(tt.content), 0o600); err != nil {
+				t.Fatalf("failed to write change log: %v", err)
+			}
+
+			r, err := NewChangeLogRepository(path, tt.transactionLimit)
+			assert.NoError(t, err)
+
+			gotTrans, gotFileMap, err := r.GetTransactions()
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+
+			assert.Equal(t, len(tt.wantTrans), len(gotTrans), "Number of transactions does not match")
+			for i := 0; i < len(tt.wantTrans) && i < len(gotTrans); i++ {
+				assert.Equal(t, tt.wantTrans[i].ID, gotTrans[i].ID)
+				assert.True(t, tt.wantTrans[i].Time.Equal(gotTrans[i].Time), "Time of transaction %d", i)
+				assert.Equal(t, tt.wantTrans[i].Author, gotTrans[i].Author)
+				assert.Equal(t, tt.wantTrans[i].Message, gotTrans[i].Message)
+				assertSetEqual(t, tt.wantTrans[i].Files, gotTrans[i].Files, "Files of transaction %d", i)
+			}
+			assert.Equal(t, tt.wantFileMap, gotFileMap, "File map does not match")
+		})
+	}
+}
+
+func TestNewChangeLogRepository_UnknownFormat(t *testing.T) {
+	t.Parallel()
+
+	_, err := NewChangeLogRepository("changes.txt", 0)
+	assert.Error(t, err)
+}
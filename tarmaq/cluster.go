@@ -0,0 +1,230 @@
+package tarmaq
+
+import (
+	"cmp"
+	"slices"
+)
+
+type Cluster struct {
+	Files []FilePath
+	// Cohesion is the ratio of the weight of edges inside the cluster to
+	// the weight of all edges of the files in the cluster.
+	Cohesion float64
+}
+
+type Clustering struct {
+	Clusters   []*Cluster
+	Modularity float64
+}
+
+// louvainGraph is an undirected weighted graph with self loops.
+// self holds twice the weight of the edges merged into a node,
+// so that the degree of a node is preserved by aggregation.
+type louvainGraph struct {
+	adj  []map[int]float64
+	self []float64
+}
+
+func (g *louvainGraph) degree(node int) float64 {
+	degree := g.self[node]
+	for _, weight := range g.adj[node] {
+		degree += weight
+	}
+
+	return degree
+}
+
+// DetectClusters detects clusters of files that change together with the Louvain method.
+// Edges are weighted by their strength. Clusters with fewer files than minSize are omitted.
+func DetectClusters(graph *CouplingGraph, minSize int) *Clustering {
+	index := make(map[FileID]int, len(graph.Nodes))
+	for i, node := range graph.Nodes {
+		index[node.ID] = i
+	}
+
+	g := &louvainGraph{
+		adj:  make([]map[int]float64, len(graph.Nodes)),
+		self: make([]float64, len(graph.Nodes)),
+	}
+	for i := range g.adj {
+		g.adj[i] = make(map[int]float64)
+	}
+	for _, edge := range graph.Edges {
+		source, okSource := index[edge.Source]
+		target, okTarget := index[edge.Target]
+		if !okSource || !okTarget || source == target {
+			continue
+		}
+		g.adj[source][target] += edge.Strength
+		g.adj[target][source] += edge.Strength
+	}
+
+	// membership maps an original node to its community in the current level
+	membership := make([]int, len(graph.Nodes))
+	for i := range membership {
+		membership[i] = i
+	}
+
+	for {
+		communities, moved := louvainLocalMoving(g)
+		if !moved {
+			break
+		}
+
+		for i := range membership {
+			membership[i] = communities[membership[i]]
+		}
+		g = louvainAggregate(g, communities)
+	}
+
+	return newClustering(graph, index, membership, minSize)
+}
+
+// louvainLocalMoving moves nodes between communities while the modularity increases.
+// It returns the renumbered community of each node and whether any node moved.
+func louvainLocalMoving(g *louvainGraph) ([]int, bool) {
+	n := len(g.adj)
+	degrees := make([]float64, n)
+	var total float64
+	for i := range n {
+		degrees[i] = g.degree(i)
+		total += degrees[i]
+	}
+
+	community := make([]int, n)
+	totals := make([]float64, n)
+	for i := range n {
+		community[i] = i
+		totals[i] = degrees[i]
+	}
+	if total == 0 {
+		return community, false
+	}
+
+	moved := false
+	for improved := true; improved; {
+		improved = false
+		for i := range n {
+			current := community[i]
+			totals[current] -= degrees[i]
+
+			links := make(map[int]float64)
+			for neighbor, weight := range g.adj[i] {
+				links[community[neighbor]] += weight
+			}
+
+			best := current
+			bestGain := links[current] - totals[current]*degrees[i]/total
+			candidates := make([]int, 0, len(links))
+			for c := range links {
+				candidates = append(candidates, c)
+			}
+			slices.Sort(candidates)
+			for _, c := range candidates {
+				gain := links[c] - totals[c]*degrees[i]/total
+				if gain > bestGain+1e-12 {
+					best = c
+					bestGain = gain
+				}
+			}
+
+			totals[best] += degrees[i]
+			if best != current {
+				community[i] = best
+				improved = true
+				moved = true
+			}
+		}
+	}
+
+	renumber := make(map[int]int)
+	for i, c := range community {
+		if _, ok := renumber[c]; !ok {
+			renumber[c] = len(renumber)
+		}
+		community[i] = renumber[c]
+	}
+
+	return community, moved
+}
+
+// louvainAggregate merges the nodes of each community into a single node.
+func louvainAggregate(g *louvainGraph, community []int) *louvainGraph {
+	n := 0
+	for _, c := range community {
+		n = max(n, c+1)
+	}
+
+	aggregated := &louvainGraph{
+		adj:  make([]map[int]float64, n),
+		self: make([]float64, n),
+	}
+	for i := range aggregated.adj {
+		aggregated.adj[i] = make(map[int]float64)
+	}
+	for i := range g.adj {
+		source := community[i]
+		aggregated.self[source] += g.self[i]
+		for j, weight := range g.adj[i] {
+			target := community[j]
+			if source == target {
+				aggregated.self[source] += weight
+				continue
+			}
+			aggregated.adj[source][target] += weight
+		}
+	}
+
+	return aggregated
+}
+
+func newClustering(graph *CouplingGraph, index map[FileID]int, membership []int, minSize int) *Clustering {
+	var (
+		total      float64
+		internal   = make(map[int]float64)
+		degrees    = make(map[int]float64)
+		filesByCom = make(map[int][]FilePath)
+	)
+	for i, node := range graph.Nodes {
+		filesByCom[membership[i]] = append(filesByCom[membership[i]], node.Path)
+	}
+	for _, edge := range graph.Edges {
+		source, target := membership[index[edge.Source]], membership[index[edge.Target]]
+		total += 2 * edge.Strength
+		degrees[source] += edge.Strength
+		degrees[target] += edge.Strength
+		if source == target {
+			internal[source] += 2 * edge.Strength
+		}
+	}
+
+	clustering := &Clustering{
+		Clusters: make([]*Cluster, 0, len(filesByCom)),
+	}
+	for c, files := range filesByCom {
+		if total > 0 {
+			clustering.Modularity += internal[c]/total - (degrees[c]/total)*(degrees[c]/total)
+		}
+
+		if len(files) < minSize {
+			continue
+		}
+
+		cluster := &Cluster{
+			Files: files,
+		}
+		if degrees[c] > 0 {
+			cluster.Cohesion = internal[c] / degrees[c]
+		}
+		clustering.Clusters = append(clustering.Clusters, cluster)
+	}
+
+	slices.SortFunc(clustering.Clusters, func(a, b *Cluster) int {
+		return cmp.Or(
+			cmp.Compare(len(b.Files), len(a.Files)),
+			cmp.Compare(a.Files[0], b.Files[0]),
+		)
+	})
+
+	return clustering
+}
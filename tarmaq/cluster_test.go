@@ -0,0 +1,81 @@
+package tarmaq
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDetectClusters(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name         string
+		transactions []*Transaction
+		fileMap      map[FileID]FilePath
+		minSize      int
+		wantClusters [][]FilePath
+	}{
+		{
+			name: "Two components",
+			transactions: []*Transaction{
+				{Files: makeFileSet(FileID(0), FileID(1), FileID(2))},
+				{Files: makeFileSet(FileID(0), FileID(1), FileID(2))},
+				{Files: makeFileSet(FileID(3), FileID(4))},
+				{Files: makeFileSet(FileID(3), FileID(4))},
+				{Files: makeFileSet(FileID(2), FileID(3))},
+			},
+			fileMap: map[FileID]FilePath{
+				FileID(0): NewFilePath("api/handler.go"),
+				FileID(1): NewFilePath("api/router.go"),
+				FileID(2): NewFilePath("web/client.ts"),
+				FileID(3): NewFilePath("db/schema.sql"),
+				FileID(4): NewFilePath("db/migrate.go"),
+			},
+			minSize: 2,
+			wantClusters: [][]FilePath{
+				{NewFilePath("api/handler.go"), NewFilePath("api/router.go"), NewFilePath("web/client.ts")},
+				{NewFilePath("db/migrate.go"), NewFilePath("db/schema.sql")},
+			},
+		},
+		{
+			name: "Isolated files are omitted",
+			transactions: []*Transaction{
+				{Files: makeFileSet(FileID(0), FileID(1))},
+				{Files: makeFileSet(FileID(2))},
+			},
+			fileMap: map[FileID]FilePath{
+				FileID(0): NewFilePath("a.go"),
+				FileID(1): NewFilePath("b.go"),
+				FileID(2): NewFilePath("c.go"),
+			},
+			minSize: 2,
+			wantClusters: [][]FilePath{
+				{NewFilePath("a.go"), NewFilePath("b.go")},
+			},
+		},
+		{
+			name:         "No transactions",
+			transactions: []*Transaction{},
+			fileMap:      map[FileID]FilePath{},
+			minSize:      1,
+			wantClusters: [][]FilePath{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			graph := NewCouplingGraph(tt.transactions, tt.fileMap, CouplingOptions{})
+			got := DetectClusters(graph, tt.minSize)
+
+			gotClusters := make([][]FilePath, 0, len(got.Clusters))
+			for _, cluster := range got.Clusters {
+				gotClusters = append(gotClusters, cluster.Files)
+			}
+			assert.Equal(t, tt.wantClusters, gotClusters)
+			assert.GreaterOrEqual(t, got.Modularity, 0.0)
+		})
+	}
+}
@@ -0,0 +1,208 @@
+package tarmaq
+
+import (
+	"cmp"
+	"slices"
+	"strings"
+)
+
+type CouplingNode struct {
+	ID      FileID
+	Path    FilePath
+	Changes uint64
+}
+
+type CouplingEdge struct {
+	Source FileID
+	Target FileID
+	// Support is the number of transactions in which both files are changed.
+	Support uint64
+	// Strength is the Jaccard index of the transactions changing each file.
+	Strength float64
+	// Confidence is the probability that Target changes when Source changes.
+	Confidence float64
+	// ReverseConfidence is the probability that Source changes when Target changes.
+	ReverseConfidence float64
+}
+
+type CouplingGraph struct {
+	Nodes []*CouplingNode
+	Edges []*CouplingEdge
+}
+
+type CouplingOptions struct {
+	// Subtree limits the graph to files under the directory. Empty means all files.
+	Subtree FilePath
+	// MinSupport is the minimum number of co-changes of an edge.
+	MinSupport uint64
+	// MinStrength is the minimum strength of an edge.
+	MinStrength float64
+}
+
+type filePair struct {
+	source FileID
+	target FileID
+}
+
+// NewCouplingGraph computes the pairwise co-change strength of files.
+// Nodes are sorted by path and edges are sorted by source and target path.
+func NewCouplingGraph(transactions []*Transaction, fileMap map[FileID]FilePath, options CouplingOptions) *CouplingGraph {
+	changes := make(map[FileID]uint64)
+	supports := make(map[filePair]uint64)
+	for _, tx := range transactions {
+		files := make([]FileID, 0, tx.Files.Len())
+		for fileID := range tx.Files.Iter() {
+			path, ok := fileMap[fileID]
+			if !ok || path == "" || !inSubtree(path, options.Subtree) {
+				continue
+			}
+			files = append(files, fileID)
+		}
+		slices.Sort(files)
+
+		for i, source := range files {
+			changes[source]++
+			for _, target := range files[i+1:] {
+				supports[filePair{source: source, target: target}]++
+			}
+		}
+	}
+
+	graph := &CouplingGraph{
+		Nodes: make([]*CouplingNode, 0, len(changes)),
+		Edges: make([]*CouplingEdge, 0, len(supports)),
+	}
+	for fileID, count := range changes {
+		graph.Nodes = append(graph.Nodes, &CouplingNode{
+			ID:      fileID,
+			Path:    fileMap[fileID],
+			Changes: count,
+		})
+	}
+	for pair, support := range supports {
+		source, target := pair.source, pair.target
+		if fileMap[source] > fileMap[target] {
+			source, target = target, source
+		}
+
+		edge := &CouplingEdge{
+			Source:            source,
+			Target:            target,
+			Support:           support,
+			Strength:          float64(support) / float64(changes[source]+changes[target]-support),
+			Confidence:        float64(support) / float64(changes[source]),
+			ReverseConfidence: float64(support) / float64(changes[target]),
+		}
+		if edge.Support < options.MinSupport || edge.Strength < options.MinStrength {
+			continue
+		}
+		graph.Edges = append(graph.Edges, edge)
+	}
+
+	slices.SortFunc(graph.Nodes, func(a, b *CouplingNode) int {
+		return cmp.Compare(a.Path, b.Path)
+	})
+	slices.SortFunc(graph.Edges, func(a, b *CouplingEdge) int {
+		return cmp.Or(
+			cmp.Compare(fileMap[a.Source], fileMap[b.Source]),
+			cmp.Compare(fileMap[a.Target], fileMap[b.Target]),
+		)
+	})
+
+	return graph
+}
+
+func inSubtree(path FilePath, subtree FilePath) bool {
+	if subtree == "" {
+		return true
+	}
+
+	dir := strings.TrimSuffix(string(subtree), "/")
+	return string(path) == dir || strings.HasPrefix(string(path), dir+"/")
+}
+
+type CoupledFile struct {
+	Path FilePath
+	// Support is the number of transactions in which both files are changed.
+	Support uint64
+	// Confidence is the probability that this file changes when the profiled file changes.
+	Confidence float64
+	// ReverseConfidence is the probability that the profiled file changes when this file changes.
+	ReverseConfidence float64
+}
+
+type FileCoupling struct {
+	Path    FilePath
+	Changes uint64
+	// Dependents are files that change when the profiled file changes, in descending order of Confidence.
+	Dependents []*CoupledFile
+	// Dependencies are files whose changes drag the profiled file along, in descending order of ReverseConfidence.
+	Dependencies []*CoupledFile
+}
+
+// NewFileCoupling computes the coupling of a single file in both directions.
+// It returns false if the file is not found in the file map.
+func NewFileCoupling(transactions []*Transaction, fileMap map[FileID]FilePath, path FilePath, minSupport uint64) (*FileCoupling, bool) {
+	fileID, ok := findFileID(fileMap, path)
+	if !ok {
+		return nil, false
+	}
+
+	var changes uint64
+	otherChanges := make(map[FileID]uint64)
+	supports := make(map[FileID]uint64)
+	for _, tx := range transactions {
+		contains := tx.Files.Contains(fileID)
+		if contains {
+			changes++
+		}
+
+		for other := range tx.Files.Iter() {
+			if other == fileID {
+				continue
+			}
+			otherChanges[other]++
+			if contains {
+				supports[other]++
+			}
+		}
+	}
+
+	coupling := &FileCoupling{
+		Path:    path,
+		Changes: changes,
+	}
+	coupled := make([]*CoupledFile, 0, len(supports))
+	for other, support := range supports {
+		otherPath, ok := fileMap[other]
+		if !ok || otherPath == "" || support < minSupport {
+			continue
+		}
+
+		coupled = append(coupled, &CoupledFile{
+			Path:              otherPath,
+			Support:           support,
+			Confidence:        float64(support) / float64(changes),
+			ReverseConfidence: float64(support) / float64(otherChanges[other]),
+		})
+	}
+
+	coupling.Dependents = slices.Clone(coupled)
+	slices.SortFunc(coupling.Dependents, func(a, b *CoupledFile) int {
+		return cmp.Or(
+			cmp.Compare(b.Confidence, a.Confidence),
+			cmp.Compare(b.Support, a.Support),
+			cmp.Compare(a.Path, b.Path),
+		)
+	})
+	coupling.Dependencies = coupled
+	slices.SortFunc(coupling.Dependencies, func(a, b *CoupledFile) int {
+		return cmp.Or(
+			cmp.Compare(b.ReverseConfidence, a.ReverseConfidence),
+			cmp.Compare(b.Support, a.Support),
+			cmp.Compare(a.Path, b.Path),
+		)
+	})
+
+	return coupling, true
+}
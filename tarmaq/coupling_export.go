@@ -0,0 +1,225 @@
+package tarmaq
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+)
+
+type GraphFormat string
+
+const (
+	GraphFormatDOT     GraphFormat = "dot"
+	GraphFormatGraphML GraphFormat = "graphml"
+	GraphFormatJSON    GraphFormat = "json"
+)
+
+// Write writes the graph in the format.
+func (g *CouplingGraph) Write(w io.Writer, format GraphFormat) error {
+	switch format {
+	case GraphFormatDOT:
+		return g.WriteDOT(w)
+	case GraphFormatGraphML:
+		return g.WriteGraphML(w)
+	case GraphFormatJSON:
+		return g.WriteJSON(w)
+	default:
+		return fmt.Errorf("unknown graph format: %s", format)
+	}
+}
+
+func (g *CouplingGraph) paths() map[FileID]string {
+	paths := make(map[FileID]string, len(g.Nodes))
+	for _, node := range g.Nodes {
+		paths[node.ID] = string(node.Path)
+	}
+
+	return paths
+}
+
+// dotWeightScale scales the strength of an edge in [0, 1] to the integer weight of Graphviz.
+const dotWeightScale = 100
+
+// WriteDOT writes the graph as an undirected Graphviz graph.
+func (g *CouplingGraph) WriteDOT(w io.Writer) error {
+	paths := g.paths()
+
+	if _, err := fmt.Fprintln(w, "graph coupling {"); err != nil {
+		return fmt.Errorf("write header: %w", err)
+	}
+	for _, node := range g.Nodes {
+		_, err := fmt.Fprintf(w, "  %s [changes=%d];\n", strconv.Quote(string(node.Path)), node.Changes)
+		if err != nil {
+			return fmt.Errorf("write node: %w", err)
+		}
+	}
+	for _, edge := range g.Edges {
+		// Graphviz requires integer weights, so the strength is written separately and scaled to the weight
+		_, err := fmt.Fprintf(w, "  %s -- %s [weight=%d, strength=%g, support=%d, confidence=%g, reverse_confidence=%g];\n",
+			strconv.Quote(paths[edge.Source]),
+			strconv.Quote(paths[edge.Target]),
+			max(int(math.Round(edge.Strength*dotWeightScale)), 1),
+			edge.Strength,
+			edge.Support,
+			edge.Confidence,
+			edge.ReverseConfidence,
+		)
+		if err != nil {
+			return fmt.Errorf("write edge: %w", err)
+		}
+	}
+	if _, err := fmt.Fprintln(w, "}"); err != nil {
+		return fmt.Errorf("write footer: %w", err)
+	}
+
+	return nil
+}
+
+type graphML struct {
+	XMLName xml.Name     `xml:"graphml"`
+	XMLNS   string       `xml:"xmlns,attr"`
+	Keys    []graphMLKey `xml:"key"`
+	Graph   graphMLGraph `xml:"graph"`
+}
+
+type graphMLKey struct {
+	ID       string `xml:"id,attr"`
+	For      string `xml:"for,attr"`
+	AttrName string `xml:"attr.name,attr"`
+	AttrType string `xml:"attr.type,attr"`
+}
+
+type graphMLGraph struct {
+	ID          string        `xml:"id,attr"`
+	EdgeDefault string        `xml:"edgedefault,attr"`
+	Nodes       []graphMLNode `xml:"node"`
+	Edges       []graphMLEdge `xml:"edge"`
+}
+
+type graphMLNode struct {
+	ID   string        `xml:"id,attr"`
+	Data []graphMLData `xml:"data"`
+}
+
+type graphMLEdge struct {
+	Source string        `xml:"source,attr"`
+	Target string        `xml:"target,attr"`
+	Data   []graphMLData `xml:"data"`
+}
+
+type graphMLData struct {
+	Key   string `xml:"key,attr"`
+	Value string `xml:",chardata"`
+}
+
+// WriteGraphML writes the graph as an undirected GraphML document.
+func (g *CouplingGraph) WriteGraphML(w io.Writer) error {
+	paths := g.paths()
+
+	doc := graphML{
+		XMLNS: "http://graphml.graphdrawing.org/xmlns",
+		Keys: []graphMLKey{
+			{ID: "changes", For: "node", AttrName: "changes", AttrType: "long"},
+			{ID: "weight", For: "edge", AttrName: "weight", AttrType: "double"},
+			{ID: "support", For: "edge", AttrName: "support", AttrType: "long"},
+			{ID: "confidence", For: "edge", AttrName: "confidence", AttrType: "double"},
+			{ID: "reverse_confidence", For: "edge", AttrName: "reverse_confidence", AttrType: "double"},
+		},
+		Graph: graphMLGraph{
+			ID:          "coupling",
+			EdgeDefault: "undirected",
+			Nodes:       make([]graphMLNode, 0, len(g.Nodes)),
+			Edges:       make([]graphMLEdge, 0, len(g.Edges)),
+		},
+	}
+	for _, node := range g.Nodes {
+		doc.Graph.Nodes = append(doc.Graph.Nodes, graphMLNode{
+			ID: string(node.Path),
+			Data: []graphMLData{
+				{Key: "changes", Value: strconv.FormatUint(node.Changes, 10)},
+			},
+		})
+	}
+	for _, edge := range g.Edges {
+		doc.Graph.Edges = append(doc.Graph.Edges, graphMLEdge{
+			Source: paths[edge.Source],
+			Target: paths[edge.Target],
+			Data: []graphMLData{
+				{Key: "weight", Value: strconv.FormatFloat(edge.Strength, 'g', -1, 64)},
+				{Key: "support", Value: strconv.FormatUint(edge.Support, 10)},
+				{Key: "confidence", Value: strconv.FormatFloat(edge.Confidence, 'g', -1, 64)},
+				{Key: "reverse_confidence", Value: strconv.FormatFloat(edge.ReverseConfidence, 'g', -1, 64)},
+			},
+		})
+	}
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return fmt.Errorf("write header: %w", err)
+	}
+	encoder := xml.NewEncoder(w)
+	encoder.Indent("", "  ")
+	if err := encoder.Encode(doc); err != nil {
+		return fmt.Errorf("encode graphml: %w", err)
+	}
+	if _, err := io.WriteString(w, "\n"); err != nil {
+		return fmt.Errorf("write footer: %w", err)
+	}
+
+	return nil
+}
+
+type jsonGraph struct {
+	Nodes []*jsonNode `json:"nodes"`
+	Edges []*jsonEdge `json:"edges"`
+}
+
+type jsonNode struct {
+	ID      string `json:"id"`
+	Changes uint64 `json:"changes"`
+}
+
+type jsonEdge struct {
+	Source            string  `json:"source"`
+	Target            string  `json:"target"`
+	Weight            float64 `json:"weight"`
+	Support           uint64  `json:"support"`
+	Confidence        float64 `json:"confidence"`
+	ReverseConfidence float64 `json:"reverse_confidence"`
+}
+
+// WriteJSON writes the graph as a JSON object of nodes and edges.
+func (g *CouplingGraph) WriteJSON(w io.Writer) error {
+	paths := g.paths()
+
+	doc := jsonGraph{
+		Nodes: make([]*jsonNode, 0, len(g.Nodes)),
+		Edges: make([]*jsonEdge, 0, len(g.Edges)),
+	}
+	for _, node := range g.Nodes {
+		doc.Nodes = append(doc.Nodes, &jsonNode{
+			ID:      string(node.Path),
+			Changes: node.Changes,
+		})
+	}
+	for _, edge := range g.Edges {
+		doc.Edges = append(doc.Edges, &jsonEdge{
+			Source:            paths[edge.Source],
+			Target:            paths[edge.Target],
+			Weight:            edge.Strength,
+			Support:           edge.Support,
+			Confidence:        edge.Confidence,
+			ReverseConfidence: edge.ReverseConfidence,
+		})
+	}
+
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(doc); err != nil {
+		return fmt.Errorf("encode json: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,115 @@
+package tarmaq
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCouplingGraph_Write(t *testing.T) {
+	t.Parallel()
+
+	graph := &CouplingGraph{
+		Nodes: []*CouplingNode{
+			{ID: FileID(0), Path: NewFilePath("a.go"), Changes: 2},
+			{ID: FileID(1), Path: NewFilePath("b.go"), Changes: 1},
+		},
+		Edges: []*CouplingEdge{
+			{Source: FileID(0), Target: FileID(1), Support: 1, Strength: 0.5, Confidence: 0.5, ReverseConfidence: 1},
+		},
+	}
+
+	tests := []struct {
+		name    string
+		format  GraphFormat
+		want    string
+		wantErr bool
+	}{
+		{
+			name:   "DOT",
+			format: GraphFormatDOT,
+			want: `graph coupling {
+  "a.go" [changes=2];
+  "b.go" [changes=1];
+  "a.go" -- "b.go" [weight=50, strength=0.5, support=1, confidence=0.5, reverse_confidence=1];
+}
+`,
+		},
+		{
+			name:   "GraphML",
+			format: GraphFormatGraphML,
+			want: `<?xml version="1.0" encoding="UTF-8"?>
+<graphml xmlns="http://graphml.graphdrawing.org/xmlns">
+  <key id="changes" for="node" attr.name="changes" attr.type="long"></key>
+  <key id="weight" for="edge" attr.name="weight" attr.type="double"></key>
+  <key id="support" for="edge" attr.name="support" attr.type="long"></key>
+  <key id="confidence" for="edge" attr.name="confidence" attr.type="double"></key>
+  <key id="reverse_confidence" for="edge" attr.name="reverse_confidence" attr.type="double"></key>
+  <graph id="coupling" edgedefault="undirected">
+    <node id="a.go">
+      <data key="changes">2</data>
+    </node>
+    <node id="b.go">
+      <data key="changes">1</data>
+    </node>
+    <edge source="a.go" target="b.go">
+      <data key="weight">0.5</data>
+      <data key="support">1</data>
+      <data key="confidence">0.5</data>
+      <data key="reverse_confidence">1</data>
+    </edge>
+  </graph>
+</graphml>
+`,
+		},
+		{
+			name:   "JSON",
+			format: GraphFormatJSON,
+			want: `{
+  "nodes": [
+    {
+      "id": "a.go",
+      "changes": 2
+    },
+    {
+      "id": "b.go",
+      "changes": 1
+    }
+  ],
+  "edges": [
+    {
+      "source": "a.go",
+      "target": "b.go",
+      "weight": 0.5,
+      "support": 1,
+      "confidence": 0.5,
+      "reverse_confidence": 1
+    }
+  ]
+}
+`,
+		},
+		{
+			name:    "Unknown format",
+			format:  GraphFormat("svg"),
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			var sb strings.Builder
+			err := graph.Write(&sb, tt.format)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, sb.String())
+		})
+	}
+}
@@ -0,0 +1,163 @@
+package tarmaq
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewCouplingGraph(t *testing.T) {
+	t.Parallel()
+
+	fileMap := map[FileID]FilePath{
+		FileID(0): NewFilePath("pkg/a.go"),
+		FileID(1): NewFilePath("pkg/b.go"),
+		FileID(2): NewFilePath("cmd/main.go"),
+	}
+	transactions := []*Transaction{
+		{Files: makeFileSet(FileID(0), FileID(1))},
+		{Files: makeFileSet(FileID(0), FileID(1), FileID(2))},
+		{Files: makeFileSet(FileID(0))},
+	}
+
+	tests := []struct {
+		name      string
+		options   CouplingOptions
+		wantNodes []*CouplingNode
+		wantEdges []*CouplingEdge
+	}{
+		{
+			name:    "All files",
+			options: CouplingOptions{},
+			wantNodes: []*CouplingNode{
+				{ID: FileID(2), Path: NewFilePath("cmd/main.go"), Changes: 1},
+				{ID: FileID(0), Path: NewFilePath("pkg/a.go"), Changes: 3},
+				{ID: FileID(1), Path: NewFilePath("pkg/b.go"), Changes: 2},
+			},
+			wantEdges: []*CouplingEdge{
+				{Source: FileID(2), Target: FileID(0), Support: 1, Strength: 1.0 / 3, Confidence: 1, ReverseConfidence: 1.0 / 3},
+				{Source: FileID(2), Target: FileID(1), Support: 1, Strength: 0.5, Confidence: 1, ReverseConfidence: 0.5},
+				{Source: FileID(0), Target: FileID(1), Support: 2, Strength: 2.0 / 3, Confidence: 2.0 / 3, ReverseConfidence: 1},
+			},
+		},
+		{
+			name:    "Subtree",
+			options: CouplingOptions{Subtree: NewFilePath("pkg/")},
+			wantNodes: []*CouplingNode{
+				{ID: FileID(0), Path: NewFilePath("pkg/a.go"), Changes: 3},
+				{ID: FileID(1), Path: NewFilePath("pkg/b.go"), Changes: 2},
+			},
+			wantEdges: []*CouplingEdge{
+				{Source: FileID(0), Target: FileID(1), Support: 2, Strength: 2.0 / 3, Confidence: 2.0 / 3, ReverseConfidence: 1},
+			},
+		},
+		{
+			name:    "Minimum support",
+			options: CouplingOptions{MinSupport: 2},
+			wantNodes: []*CouplingNode{
+				{ID: FileID(2), Path: NewFilePath("cmd/main.go"), Changes: 1},
+				{ID: FileID(0), Path: NewFilePath("pkg/a.go"), Changes: 3},
+				{ID: FileID(1), Path: NewFilePath("pkg/b.go"), Changes: 2},
+			},
+			wantEdges: []*CouplingEdge{
+				{Source: FileID(0), Target: FileID(1), Support: 2, Strength: 2.0 / 3, Confidence: 2.0 / 3, ReverseConfidence: 1},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			got := NewCouplingGraph(transactions, fileMap, tt.options)
+
+			assert.Equal(t, tt.wantNodes, got.Nodes)
+			assert.Len(t, got.Edges, len(tt.wantEdges))
+			for i := range min(len(got.Edges), len(tt.wantEdges)) {
+				assert.Equal(t, tt.wantEdges[i].Source, got.Edges[i].Source, "Source of edge %d", i)
+				assert.Equal(t, tt.wantEdges[i].Target, got.Edges[i].Target, "Target of edge %d", i)
+				assert.Equal(t, tt.wantEdges[i].Support, got.Edges[i].Support, "Support of edge %d", i)
+				assert.InDelta(t, tt.wantEdges[i].Strength, got.Edges[i].Strength, 1e-9, "Strength of edge %d", i)
+				assert.InDelta(t, tt.wantEdges[i].Confidence, got.Edges[i].Confidence, 1e-9, "Confidence of edge %d", i)
+				assert.InDelta(t, tt.wantEdges[i].ReverseConfidence, got.Edges[i].ReverseConfidence, 1e-9, "ReverseConfidence of edge %d", i)
+			}
+		})
+	}
+}
+
+func TestNewFileCoupling(t *testing.T) {
+	t.Parallel()
+
+	fileMap := map[FileID]FilePath{
+		FileID(0): NewFilePath("api.go"),
+		FileID(1): NewFilePath("client.go"),
+		FileID(2): NewFilePath("util.go"),
+	}
+	transactions := []*Transaction{
+		{Files: makeFileSet(FileID(0), FileID(1))},
+		{Files: makeFileSet(FileID(0), FileID(1))},
+		{Files: makeFileSet(FileID(0), FileID(2))},
+		{Files: makeFileSet(FileID(0))},
+		{Files: makeFileSet(FileID(2))},
+		{Files: makeFileSet(FileID(2))},
+		{Files: makeFileSet(FileID(2))},
+	}
+
+	tests := []struct {
+		name             string
+		path             FilePath
+		minSupport       uint64
+		wantOK           bool
+		wantChanges      uint64
+		wantDependents   []*CoupledFile
+		wantDependencies []*CoupledFile
+	}{
+		{
+			name:        "Asymmetric coupling",
+			path:        NewFilePath("api.go"),
+			minSupport:  1,
+			wantOK:      true,
+			wantChanges: 4,
+			wantDependents: []*CoupledFile{
+				{Path: NewFilePath("client.go"), Support: 2, Confidence: 0.5, ReverseConfidence: 1},
+				{Path: NewFilePath("util.go"), Support: 1, Confidence: 0.25, ReverseConfidence: 0.25},
+			},
+			wantDependencies: []*CoupledFile{
+				{Path: NewFilePath("client.go"), Support: 2, Confidence: 0.5, ReverseConfidence: 1},
+				{Path: NewFilePath("util.go"), Support: 1, Confidence: 0.25, ReverseConfidence: 0.25},
+			},
+		},
+		{
+			name:             "Minimum support",
+			path:             NewFilePath("util.go"),
+			minSupport:       2,
+			wantOK:           true,
+			wantChanges:      4,
+			wantDependents:   []*CoupledFile{},
+			wantDependencies: []*CoupledFile{},
+		},
+		{
+			name:       "Unknown file",
+			path:       NewFilePath("unknown.go"),
+			minSupport: 1,
+			wantOK:     false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			got, ok := NewFileCoupling(transactions, fileMap, tt.path, tt.minSupport)
+			assert.Equal(t, tt.wantOK, ok)
+			if !tt.wantOK {
+				return
+			}
+
+			assert.Equal(t, tt.path, got.Path)
+			assert.Equal(t, tt.wantChanges, got.Changes)
+			assert.Equal(t, tt.wantDependents, got.Dependents)
+			assert.Equal(t, tt.wantDependencies, got.Dependencies)
+		})
+	}
+}
@@ -0,0 +1,265 @@
+package tarmaq
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/mazrean/mcp-tarmaq/pkg/collection"
+)
+
+var _ Repository = &CrossRepository{}
+
+// DefaultTicketPattern matches issue keys such as "PROJ-123".
+var DefaultTicketPattern = regexp.MustCompile(`\b[A-Z][A-Z0-9]+-[0-9]+\b`)
+
+// wellKnownTicketKeys are the keys of tokens which look like tickets but name standards,
+// such as UTF-8, SHA-256 and ISO-8601.
+var wellKnownTicketKeys = collection.NewSet("AES", "CVE", "CWE", "ECMA", "HTTP", "ISO", "PEP", "RFC", "RSA", "SHA", "UTF")
+
+// CrossRepository groups commits of several repositories into joint transactions,
+// so that coupling across repositories can be mined.
+// Files are identified by "<repository name>:<path>".
+// Authors are matched after the .mailmap files of the repositories implementing AuthorReporter are applied.
+type CrossRepository struct {
+	names         []string
+	repositories  []Repository
+	ticketPattern *regexp.Regexp
+	authorWindow  time.Duration
+}
+
+type CrossRepositoryOption func(*CrossRepository)
+
+// WithTicketPattern groups commits of different repositories whose messages contain the same match of the pattern.
+// Matches naming standards, such as UTF-8, are ignored. A nil pattern disables grouping by tickets.
+func WithTicketPattern(pattern *regexp.Regexp) CrossRepositoryOption {
+	return func(r *CrossRepository) {
+		r.ticketPattern = pattern
+	}
+}
+
+// WithAuthorWindow groups commits of the same author in different repositories
+// made within the window from the first of them. Zero disables grouping by authors.
+func WithAuthorWindow(window time.Duration) CrossRepositoryOption {
+	return func(r *CrossRepository) {
+		r.authorWindow = window
+	}
+}
+
+func NewCrossRepository(names []string, repositories []Repository, options ...CrossRepositoryOption) (*CrossRepository, error) {
+	if len(names) != len(repositories) {
+		return nil, fmt.Errorf("number of names (%d) and repositories (%d) mismatch", len(names), len(repositories))
+	}
+
+	r := &CrossRepository{
+		names:         names,
+		repositories:  repositories,
+		ticketPattern: DefaultTicketPattern,
+		authorWindow:  time.Hour,
+	}
+	for _, option := range options {
+		option(r)
+	}
+
+	return r, nil
+}
+
+// CrossRepositoryPath returns the path of a file of a repository in a CrossRepository.
+func CrossRepositoryPath(name string, path FilePath) FilePath {
+	return FilePath(name + ":" + string(path))
+}
+
+type crossCommit struct {
+	repository int
+	tx         *Transaction
+	fileIDs    map[FileID]FileID
+}
+
+func (r *CrossRepository) GetTransactions() ([]*Transaction, map[FileID]FilePath, error) {
+	idGenerator := FileIDGenerator{0}
+	fileMap := make(map[FileID]FilePath)
+
+	var commits []*crossCommit
+	var mailmaps []*Mailmap
+	for i, repo := range r.repositories {
+		transactions, repoFileMap, err := repo.GetTransactions()
+		if err != nil {
+			return nil, nil, fmt.Errorf("get transactions of %s: %w", r.names[i], err)
+		}
+
+		if reporter, ok := repo.(AuthorReporter); ok {
+			mailmap, err := reporter.Mailmap()
+			if err != nil {
+				return nil, nil, fmt.Errorf("get mailmap of %s: %w", r.names[i], err)
+			}
+			mailmaps = append(mailmaps, mailmap)
+		}
+
+		fileIDs := make(map[FileID]FileID, len(repoFileMap))
+		for id, path := range repoFileMap {
+			crossID := idGenerator.Next()
+			fileIDs[id] = crossID
+			fileMap[crossID] = CrossRepositoryPath(r.names[i], path)
+		}
+
+		for _, tx := range transactions {
+			commits = append(commits, &crossCommit{
+				repository: i,
+				tx:         tx,
+				fileIDs:    fileIDs,
+			})
+		}
+	}
+
+	groups := r.group(commits, MergeMailmaps(mailmaps...))
+
+	transactions := make([]*Transaction, 0, len(groups))
+	for _, group := range groups {
+		transactions = append(transactions, mergeCrossCommits(group, r.names))
+	}
+	slices.SortStableFunc(transactions, func(a, b *Transaction) int {
+		return b.Time.Compare(a.Time)
+	})
+
+	return transactions, fileMap, nil
+}
+
+// group groups commits with union-find, keeping the order of commits in each group.
+// Authors are compared after the mailmap is applied.
+func (r *CrossRepository) group(commits []*crossCommit, mailmap *Mailmap) [][]*crossCommit {
+	parents := make([]int, len(commits))
+	for i := range parents {
+		parents[i] = i
+	}
+	var find func(int) int
+	find = func(i int) int {
+		if parents[i] != i {
+			parents[i] = find(parents[i])
+		}
+		return parents[i]
+	}
+	// unionAcross unions the commits if they span several repositories,
+	// since commits of a single repository are already transactions on their own
+	unionAcross := func(indices []int) {
+		repositories := collection.NewSet[int]()
+		for _, i := range indices {
+			repositories.Add(commits[i].repository)
+		}
+		if repositories.Len() < 2 {
+			return
+		}
+		for _, i := range indices[1:] {
+			parents[find(i)] = find(indices[0])
+		}
+	}
+
+	if r.ticketPattern != nil {
+		tickets := make(map[string][]int)
+		var keys []string
+		for i, commit := range commits {
+			for _, ticket := range r.ticketPattern.FindAllString(commit.tx.Message, -1) {
+				if key, _, _ := strings.Cut(ticket, "-"); wellKnownTicketKeys.Contains(key) {
+					continue
+				}
+				if _, ok := tickets[ticket]; !ok {
+					keys = append(keys, ticket)
+				}
+				tickets[ticket] = append(tickets[ticket], i)
+			}
+		}
+		for _, ticket := range keys {
+			unionAcross(tickets[ticket])
+		}
+	}
+
+	if r.authorWindow > 0 {
+		order := make([]int, len(commits))
+		for i := range order {
+			order[i] = i
+		}
+		slices.SortStableFunc(order, func(i, j int) int {
+			return commits[i].tx.Time.Compare(commits[j].tx.Time)
+		})
+
+		// a session of an author is closed at the end of the window from its first commit,
+		// so that steady work does not chain into a single group
+		sessions := make(map[string][]int)
+		for _, i := range order {
+			if commits[i].tx.Author == "" {
+				continue
+			}
+			author := mailmap.Email(commits[i].tx.Author)
+
+			session, ok := sessions[author]
+			if ok && commits[i].tx.Time.Sub(commits[session[0]].tx.Time) > r.authorWindow {
+				unionAcross(session)
+				session = nil
+			}
+			sessions[author] = append(session, i)
+		}
+		for _, session := range sessions {
+			unionAcross(session)
+		}
+	}
+
+	groupIndex := make(map[int]int)
+	var groups [][]*crossCommit
+	for i, commit := range commits {
+		root := find(i)
+		index, ok := groupIndex[root]
+		if !ok {
+			index = len(groups)
+			groupIndex[root] = index
+			groups = append(groups, nil)
+		}
+		groups[index] = append(groups[index], commit)
+	}
+
+	return groups
+}
+
+func mergeCrossCommits(commits []*crossCommit, names []string) *Transaction {
+	latest := slices.MaxFunc(commits, func(a, b *crossCommit) int {
+		return a.tx.Time.Compare(b.tx.Time)
+	})
+
+	ids := make([]string, 0, len(commits))
+	tx := &Transaction{
+		Time:      latest.tx.Time,
+		Author:    latest.tx.Author,
+		Committer: latest.tx.Committer,
+		Message:   latest.tx.Message,
+		Files:     collection.NewSet[FileID](),
+	}
+	for _, commit := range commits {
+		ids = append(ids, names[commit.repository]+":"+commit.tx.ID)
+
+		for fileID := range commit.tx.Files.Iter() {
+			tx.Files.Add(commit.fileIDs[fileID])
+		}
+		for fileID, lines := range commit.tx.Churn {
+			if tx.Churn == nil {
+				tx.Churn = make(map[FileID]uint64)
+			}
+			tx.Churn[commit.fileIDs[fileID]] += lines
+		}
+		for _, rename := range commit.tx.Renames {
+			tx.Renames = append(tx.Renames, &Rename{
+				File: commit.fileIDs[rename.File],
+				From: CrossRepositoryPath(names[commit.repository], rename.From),
+				To:   CrossRepositoryPath(names[commit.repository], rename.To),
+			})
+		}
+		for fileID := range commit.tx.Deleted.Iter() {
+			if tx.Deleted == nil {
+				tx.Deleted = collection.NewSet[FileID]()
+			}
+			tx.Deleted.Add(commit.fileIDs[fileID])
+		}
+	}
+	tx.ID = strings.Join(ids, ",")
+
+	return tx
+}
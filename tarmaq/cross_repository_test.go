@@ -0,0 +1,273 @@
+package tarmaq
+
+import (
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type mockRepository struct {
+	transactions []*Transaction
+	fileMap      map[FileID]FilePath
+}
+
+func (r *mockRepository) GetTransactions() ([]*Transaction, map[FileID]FilePath, error) {
+	return r.transactions, r.fileMap, nil
+}
+
+func TestCrossRepository_GetTransactions(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2025, 1, 31, 0, 0, 0, 0, time.UTC)
+	api := &mockRepository{
+		transactions: []*Transaction{
+			{ID: "a2", Time: now.Add(-10 * time.Minute), Author: "alice@example.com", Message: "Add field", Files: makeFileSet(FileID(0))},
+			{ID: "a1", Time: now.Add(-48 * time.Hour), Author: "bob@example.com", Message: "PROJ-1: Add endpoint", Files: makeFileSet(FileID(0), FileID(1))},
+		},
+		fileMap: map[FileID]FilePath{
+			FileID(0): NewFilePath("schema.json"),
+			FileID(1): NewFilePath("handler.go"),
+		},
+	}
+	web := &mockRepository{
+		transactions: []*Transaction{
+			{ID: "w2", Time: now, Author: "Alice@example.com", Message: "Use field", Files: makeFileSet(FileID(0))},
+			{ID: "w1", Time: now.Add(-24 * time.Hour), Author: "carol@example.com", Message: "Call endpoint (PROJ-1)", Files: makeFileSet(FileID(0))},
+		},
+		fileMap: map[FileID]FilePath{
+			FileID(0): NewFilePath("client.ts"),
+		},
+	}
+
+	tests := []struct {
+		name      string
+		options   []CrossRepositoryOption
+		wantPaths [][]FilePath
+		wantIDs   []string
+	}{
+		{
+			name: "Group by ticket and author",
+			wantPaths: [][]FilePath{
+				{"api:schema.json", "web:client.ts"},
+				{"api:handler.go", "api:schema.json", "web:client.ts"},
+			},
+			wantIDs: []string{"api:a2,web:w2", "api:a1,web:w1"},
+		},
+		{
+			name:    "Group by ticket only",
+			options: []CrossRepositoryOption{WithAuthorWindow(0)},
+			wantPaths: [][]FilePath{
+				{"web:client.ts"},
+				{"api:schema.json"},
+				{"api:handler.go", "api:schema.json", "web:client.ts"},
+			},
+			wantIDs: []string{"web:w2", "api:a2", "api:a1,web:w1"},
+		},
+		{
+			name:    "Author window too short",
+			options: []CrossRepositoryOption{WithTicketPattern(nil), WithAuthorWindow(5 * time.Minute)},
+			wantPaths: [][]FilePath{
+				{"web:client.ts"},
+				{"api:schema.json"},
+				{"web:client.ts"},
+				{"api:handler.go", "api:schema.json"},
+			},
+			wantIDs: []string{"web:w2", "api:a2", "web:w1", "api:a1"},
+		},
+		{
+			name:    "Custom ticket pattern",
+			options: []CrossRepositoryOption{WithTicketPattern(regexp.MustCompile(`field`)), WithAuthorWindow(0)},
+			wantPaths: [][]FilePath{
+				{"api:schema.json", "web:client.ts"},
+				{"web:client.ts"},
+				{"api:handler.go", "api:schema.json"},
+			},
+			wantIDs: []string{"api:a2,web:w2", "web:w1", "api:a1"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			r, err := NewCrossRepository([]string{"api", "web"}, []Repository{api, web}, tt.options...)
+			assert.NoError(t, err)
+
+			gotTrans, gotFileMap, err := r.GetTransactions()
+			assert.NoError(t, err)
+
+			gotPaths := make([][]FilePath, 0, len(gotTrans))
+			gotIDs := make([]string, 0, len(gotTrans))
+			for _, tx := range gotTrans {
+				paths := make([]FilePath, 0, tx.Files.Len())
+				for fileID := range tx.Files.Iter() {
+					paths = append(paths, gotFileMap[fileID])
+				}
+				gotPaths = append(gotPaths, paths)
+				gotIDs = append(gotIDs, tx.ID)
+			}
+
+			assert.Len(t, gotPaths, len(tt.wantPaths))
+			for i := range min(len(gotPaths), len(tt.wantPaths)) {
+				assert.ElementsMatch(t, tt.wantPaths[i], gotPaths[i], "Files of transaction %d", i)
+			}
+			assert.Equal(t, tt.wantIDs, gotIDs)
+		})
+	}
+}
+
+type mockAuthorRepository struct {
+	mockRepository
+	mailmap *Mailmap
+}
+
+func (r *mockAuthorRepository) Identity() (string, error) {
+	return "", nil
+}
+
+func (r *mockAuthorRepository) Mailmap() (*Mailmap, error) {
+	return r.mailmap, nil
+}
+
+func TestCrossRepository_Mailmap(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2025, 1, 31, 0, 0, 0, 0, time.UTC)
+	mailmap := NewMailmap()
+	mailmap.Add("alice@old.example.com", "alice@example.com")
+	api := &mockAuthorRepository{
+		mockRepository: mockRepository{
+			transactions: []*Transaction{
+				{ID: "a1", Time: now.Add(-10 * time.Minute), Author: "alice@old.example.com", Committer: "alice@old.example.com", Message: "Add field", Files: makeFileSet(FileID(0))},
+			},
+			fileMap: map[FileID]FilePath{FileID(0): NewFilePath("schema.json")},
+		},
+		mailmap: mailmap,
+	}
+	web := &mockRepository{
+		transactions: []*Transaction{
+			{ID: "w1", Time: now, Author: "alice@example.com", Committer: "ci@example.com", Message: "Use field", Files: makeFileSet(FileID(0))},
+		},
+		fileMap: map[FileID]FilePath{FileID(0): NewFilePath("client.ts")},
+	}
+
+	r, err := NewCrossRepository([]string{"api", "web"}, []Repository{api, web})
+	assert.NoError(t, err)
+
+	gotTrans, _, err := r.GetTransactions()
+	assert.NoError(t, err)
+
+	if assert.Len(t, gotTrans, 1) {
+		assert.Equal(t, "api:a1,web:w1", gotTrans[0].ID)
+		assert.Equal(t, "alice@example.com", gotTrans[0].Author)
+		assert.Equal(t, "ci@example.com", gotTrans[0].Committer)
+	}
+}
+
+func TestNewCrossRepository(t *testing.T) {
+	t.Parallel()
+
+	_, err := NewCrossRepository([]string{"api"}, []Repository{})
+	assert.Error(t, err)
+}
+
+func TestCrossRepository_group(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2025, 1, 31, 0, 0, 0, 0, time.UTC)
+	commit := func(repository int, id string, offset time.Duration, message string) *crossCommit {
+		return &crossCommit{
+			repository: repository,
+			tx:         &Transaction{ID: id, Time: now.Add(offset), Author: "alice@example.com", Message: message},
+		}
+	}
+
+	alias := commit(1, "w1", 10*time.Minute, "Step 2")
+	alias.tx.Author = "alice@old.example.com"
+	mailmap := NewMailmap()
+	mailmap.Add("alice@old.example.com", "alice@example.com")
+
+	tests := []struct {
+		name    string
+		commits []*crossCommit
+		mailmap *Mailmap
+		wantIDs [][]string
+	}{
+		{
+			name: "Steady work does not chain",
+			commits: []*crossCommit{
+				commit(0, "a1", 0, "Step 1"),
+				commit(1, "w1", 40*time.Minute, "Step 2"),
+				commit(0, "a2", 80*time.Minute, "Step 3"),
+				commit(1, "w2", 120*time.Minute, "Step 4"),
+			},
+			wantIDs: [][]string{{"a1", "w1"}, {"a2", "w2"}},
+		},
+		{
+			name: "Single repository in the window",
+			commits: []*crossCommit{
+				commit(0, "a1", 0, "Step 1"),
+				commit(0, "a2", 10*time.Minute, "Step 2"),
+			},
+			wantIDs: [][]string{{"a1"}, {"a2"}},
+		},
+		{
+			name: "Well-known tokens are not tickets",
+			commits: []*crossCommit{
+				commit(0, "a1", 0, "Read UTF-8 and SHA-256"),
+				commit(1, "w1", 48*time.Hour, "Write UTF-8"),
+				commit(0, "a2", 96*time.Hour, "Parse ISO-8601 dates"),
+				commit(1, "w2", 144*time.Hour, "Format ISO-8601 dates (PROJ-1)"),
+				commit(0, "a3", 192*time.Hour, "PROJ-1: Fix SHA-256"),
+			},
+			wantIDs: [][]string{{"a1"}, {"w1"}, {"a2"}, {"w2", "a3"}},
+		},
+		{
+			name: "Ticket in a single repository",
+			commits: []*crossCommit{
+				commit(0, "a1", 0, "PROJ-1: Add endpoint"),
+				commit(0, "a2", 48*time.Hour, "PROJ-1: Fix endpoint"),
+			},
+			wantIDs: [][]string{{"a1"}, {"a2"}},
+		},
+		{
+			name: "Aliases of an author",
+			commits: []*crossCommit{
+				commit(0, "a1", 0, "Step 1"),
+				alias,
+			},
+			mailmap: mailmap,
+			wantIDs: [][]string{{"a1", "w1"}},
+		},
+		{
+			name: "Aliases without a mailmap",
+			commits: []*crossCommit{
+				commit(0, "a1", 0, "Step 1"),
+				alias,
+			},
+			wantIDs: [][]string{{"a1"}, {"w1"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			r, err := NewCrossRepository([]string{"api", "web"}, []Repository{&mockRepository{}, &mockRepository{}})
+			assert.NoError(t, err)
+
+			groups := r.group(tt.commits, tt.mailmap)
+			gotIDs := make([][]string, 0, len(groups))
+			for _, group := range groups {
+				ids := make([]string, 0, len(group))
+				for _, commit := range group {
+					ids = append(ids, commit.tx.ID)
+				}
+				gotIDs = append(gotIDs, ids)
+			}
+			assert.Equal(t, tt.wantIDs, gotIDs)
+		})
+	}
+}
@@ -0,0 +1,192 @@
+package tarmaq
+
+import (
+	"cmp"
+	"path"
+	"slices"
+	"strings"
+
+	"github.com/mazrean/mcp-tarmaq/pkg/collection"
+)
+
+// FallbackSource is the heuristic a fallback suggestion is derived from.
+type FallbackSource string
+
+const (
+	// FallbackSameName suggests files with the same base name in the same or a mirrored directory,
+	// such as an implementation and its test. It has no history, so it is ranked below the other sources.
+	FallbackSameName FallbackSource = "same_name"
+	// FallbackSibling suggests files in the same directory which change with the files of the directory.
+	FallbackSibling FallbackSource = "sibling"
+	// FallbackDirectory suggests files in other directories which change with the files of the directory.
+	FallbackDirectory FallbackSource = "directory"
+)
+
+// Fallback is a suggestion for files without history.
+// It has lower evidence than the association rules, since it is not based on changes of the files themselves.
+type Fallback struct {
+	Path   FilePath
+	Source FallbackSource
+	// Dir is the directory whose changes the suggestion is based on. It is empty for FallbackSameName.
+	Dir FilePath
+	// Support is the number of transactions changing both the directory and the file.
+	Support uint64
+	// Confidence is the ratio of the transactions changing the directory which also changed the file.
+	// It is 0 for FallbackSameName, which is not based on history.
+	Confidence float64
+}
+
+// Fallbacks suggests files related to files without history, by their names and the coupling of their directories.
+// Directories without history are replaced with their closest parent with history.
+// Removed files and the files themselves are not suggested. Zero limit means no limit.
+func Fallbacks(transactions []*Transaction, fileMap map[FileID]FilePath, files []FilePath, limit int) []*Fallback {
+	removed := RemovedFiles(transactions)
+	query := collection.NewSet(files...)
+	candidates := make(map[FileID]*Fallback)
+
+	for id, p := range fileMap {
+		if p == "" || removed.Contains(id) || query.Contains(p) {
+			continue
+		}
+		for _, file := range files {
+			if fileStem(p) == fileStem(file) && mirroredDir(p, file) {
+				candidates[id] = &Fallback{
+					Path:   p,
+					Source: FallbackSameName,
+				}
+				break
+			}
+		}
+	}
+
+	dirs := collection.NewSet[FilePath]()
+	for _, file := range files {
+		dirs.Add(FilePath(path.Dir(string(file))))
+	}
+	for dir := range dirs.Iter() {
+		dir, dirTxs := dirTransactions(transactions, fileMap, dir)
+		if len(dirTxs) == 0 {
+			continue
+		}
+
+		supports := make(map[FileID]uint64)
+		for _, tx := range dirTxs {
+			for id := range tx.Files.Iter() {
+				supports[id]++
+			}
+		}
+
+		for id, support := range supports {
+			p, ok := fileMap[id]
+			if !ok || p == "" || removed.Contains(id) || query.Contains(p) {
+				continue
+			}
+
+			fallback := &Fallback{
+				Path:       p,
+				Source:     FallbackDirectory,
+				Dir:        dir,
+				Support:    support,
+				Confidence: float64(support) / float64(len(dirTxs)),
+			}
+			if FilePath(path.Dir(string(p))) == dir {
+				fallback.Source = FallbackSibling
+			}
+
+			if current, ok := candidates[id]; ok && current.Source != FallbackSameName && current.Confidence >= fallback.Confidence {
+				continue
+			}
+			candidates[id] = fallback
+		}
+	}
+
+	fallbacks := make([]*Fallback, 0, len(candidates))
+	for _, fallback := range candidates {
+		fallbacks = append(fallbacks, fallback)
+	}
+	slices.SortFunc(fallbacks, func(a, b *Fallback) int {
+		if (a.Source == FallbackSameName) != (b.Source == FallbackSameName) {
+			if a.Source == FallbackSameName {
+				return 1
+			}
+			return -1
+		}
+		return cmp.Or(
+			cmp.Compare(b.Confidence, a.Confidence),
+			cmp.Compare(b.Support, a.Support),
+			cmp.Compare(a.Path, b.Path),
+		)
+	})
+	if limit > 0 && len(fallbacks) > limit {
+		fallbacks = fallbacks[:limit]
+	}
+
+	return fallbacks
+}
+
+// dirTransactions returns the transactions changing files directly under dir,
+// or under its closest parent changed by any transaction.
+func dirTransactions(transactions []*Transaction, fileMap map[FileID]FilePath, dir FilePath) (FilePath, []*Transaction) {
+	for {
+		var dirTxs []*Transaction
+		for _, tx := range transactions {
+			for id := range tx.Files.Iter() {
+				if p, ok := fileMap[id]; ok && FilePath(path.Dir(string(p))) == dir {
+					dirTxs = append(dirTxs, tx)
+					break
+				}
+			}
+		}
+		if len(dirTxs) > 0 || dir == "." {
+			return dir, dirTxs
+		}
+
+		dir = FilePath(path.Dir(string(dir)))
+	}
+}
+
+// mirroredDir reports whether the files are in the same directory, or in directories mirrored by test roots,
+// e.g. src/main/java/foo and src/test/java/foo, lib and spec/lib, or web and web/__tests__.
+func mirroredDir(a FilePath, b FilePath) bool {
+	return sourceDir(a) == sourceDir(b)
+}
+
+// sourceDir returns the directory of the file without test roots and leading source roots.
+func sourceDir(p FilePath) string {
+	var dirs []string
+	for _, dir := range strings.Split(path.Dir(string(p)), "/") {
+		switch {
+		case dir == "." || dir == "test" || dir == "tests" || dir == "__tests__" || dir == "spec" || dir == "specs":
+			continue
+		case dir == "main" && len(dirs) > 0 && dirs[len(dirs)-1] == "src":
+			// src/main mirrors src/test in Maven and Gradle layouts
+			continue
+		}
+		dirs = append(dirs, dir)
+	}
+	if len(dirs) > 0 && (dirs[0] == "src" || dirs[0] == "lib") {
+		dirs = dirs[1:]
+	}
+
+	return strings.Join(dirs, "/")
+}
+
+// fileStem returns the base name of a file without extensions and test affixes,
+// e.g. foo for foo.go, foo_test.go, foo.spec.ts and test_foo.py, and Foo for FooTest.java.
+func fileStem(p FilePath) string {
+	name := path.Base(string(p))
+	if i := strings.Index(name[1:], "."); i >= 0 {
+		name = name[:i+1]
+	}
+
+	for _, suffix := range []string{"_test", "Tests", "Test", "_spec", "Spec"} {
+		if stem, ok := strings.CutSuffix(name, suffix); ok && stem != "" {
+			return stem
+		}
+	}
+	if stem, ok := strings.CutPrefix(name, "test_"); ok && stem != "" {
+		return stem
+	}
+
+	return name
+}
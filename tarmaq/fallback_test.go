@@ -0,0 +1,139 @@
+package tarmaq
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFallbacks(t *testing.T) {
+	t.Parallel()
+
+	fileMap := map[FileID]FilePath{
+		FileID(0): NewFilePath("api/user.go"),
+		FileID(1): NewFilePath("api/router.go"),
+		FileID(2): NewFilePath("docs/api.md"),
+		FileID(3): NewFilePath("api/legacy.go"),
+		FileID(4): NewFilePath("web/user.spec.ts"),
+		FileID(5): NewFilePath("src/main/java/Order.java"),
+		FileID(6): NewFilePath("src/main/java/Item.java"),
+	}
+	transactions := []*Transaction{
+		{Files: makeFileSet(FileID(0), FileID(1), FileID(2))},
+		{Files: makeFileSet(FileID(1), FileID(3)), Deleted: makeFileSet(FileID(3))},
+		{Files: makeFileSet(FileID(0), FileID(1))},
+		{Files: makeFileSet(FileID(4))},
+		{Files: makeFileSet(FileID(5), FileID(6))},
+	}
+
+	tests := []struct {
+		name  string
+		files []FilePath
+		limit int
+		want  []*Fallback
+	}{
+		{
+			name:  "New file in a directory with history",
+			files: []FilePath{NewFilePath("api/order.go")},
+			want: []*Fallback{
+				{Path: NewFilePath("api/router.go"), Source: FallbackSibling, Dir: NewFilePath("api"), Support: 3, Confidence: 1},
+				{Path: NewFilePath("api/user.go"), Source: FallbackSibling, Dir: NewFilePath("api"), Support: 2, Confidence: 2.0 / 3},
+				{Path: NewFilePath("docs/api.md"), Source: FallbackDirectory, Dir: NewFilePath("api"), Support: 1, Confidence: 1.0 / 3},
+			},
+		},
+		{
+			name:  "Test of a file",
+			files: []FilePath{NewFilePath("api/user_test.go")},
+			limit: 2,
+			want: []*Fallback{
+				{Path: NewFilePath("api/router.go"), Source: FallbackSibling, Dir: NewFilePath("api"), Support: 3, Confidence: 1},
+				{Path: NewFilePath("api/user.go"), Source: FallbackSibling, Dir: NewFilePath("api"), Support: 2, Confidence: 2.0 / 3},
+			},
+		},
+		{
+			name:  "New directory",
+			files: []FilePath{NewFilePath("web/components/user.ts")},
+			want: []*Fallback{
+				{Path: NewFilePath("web/user.spec.ts"), Source: FallbackSibling, Dir: NewFilePath("web"), Support: 1, Confidence: 1},
+			},
+		},
+		{
+			name:  "Same name in a mirrored test root",
+			files: []FilePath{NewFilePath("src/test/java/OrderTest.java")},
+			want: []*Fallback{
+				{Path: NewFilePath("src/main/java/Order.java"), Source: FallbackSameName},
+			},
+		},
+		{
+			name:  "Same name ranked below history",
+			files: []FilePath{NewFilePath("api/order.go"), NewFilePath("src/test/java/OrderTest.java")},
+			want: []*Fallback{
+				{Path: NewFilePath("api/router.go"), Source: FallbackSibling, Dir: NewFilePath("api"), Support: 3, Confidence: 1},
+				{Path: NewFilePath("api/user.go"), Source: FallbackSibling, Dir: NewFilePath("api"), Support: 2, Confidence: 2.0 / 3},
+				{Path: NewFilePath("docs/api.md"), Source: FallbackDirectory, Dir: NewFilePath("api"), Support: 1, Confidence: 1.0 / 3},
+				{Path: NewFilePath("src/main/java/Order.java"), Source: FallbackSameName},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			got := Fallbacks(transactions, fileMap, tt.files, tt.limit)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestMirroredDir(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		a    string
+		b    string
+		want bool
+	}{
+		{a: "pkg/foo.go", b: "pkg/foo_test.go", want: true},
+		{a: "src/main/java/com/x/Foo.java", b: "src/test/java/com/x/FooTest.java", want: true},
+		{a: "lib/foo.rb", b: "spec/lib/foo_spec.rb", want: true},
+		{a: "web/x.ts", b: "web/__tests__/x.test.ts", want: true},
+		{a: "foo/x.py", b: "tests/foo/test_x.py", want: true},
+		{a: "api/user.go", b: "web/user.ts", want: false},
+		{a: "cmd/a/main.go", b: "cmd/b/main.go", want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.a+" "+tt.b, func(t *testing.T) {
+			t.Parallel()
+
+			assert.Equal(t, tt.want, mirroredDir(NewFilePath(tt.a), NewFilePath(tt.b)))
+		})
+	}
+}
+
+func TestFileStem(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		path string
+		want string
+	}{
+		{path: "pkg/foo.go", want: "foo"},
+		{path: "pkg/foo_test.go", want: "foo"},
+		{path: "src/Foo.java", want: "Foo"},
+		{path: "test/FooTest.java", want: "Foo"},
+		{path: "src/x.spec.ts", want: "x"},
+		{path: "tests/test_x.py", want: "x"},
+		{path: ".gitignore", want: ".gitignore"},
+		{path: "Test.java", want: "Test"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			t.Parallel()
+
+			assert.Equal(t, tt.want, fileStem(NewFilePath(tt.path)))
+		})
+	}
+}
@@ -0,0 +1,556 @@
+package tarmaq
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"io"
+	"log/slog"
+	"maps"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/mazrean/mcp-tarmaq/pkg/collection"
+)
+
+var _ Repository = &FastImportRepository{}
+
+// FastImportRepository reads transactions from a stream in the git fast-import format,
+// which is exported by hg-fast-export, fossil export and other tools.
+// The commits of all branches in the stream are mined.
+type FastImportRepository struct {
+	path             string
+	transactionLimit int
+}
+
+func NewFastImportRepository(path string, transactionLimit int) (*FastImportRepository, error) {
+	if _, err := os.Stat(path); err != nil {
+		return nil, fmt.Errorf("stat fast-import stream: %w", err)
+	}
+
+	return &FastImportRepository{
+		path:             path,
+		transactionLimit: transactionLimit,
+	}, nil
+}
+
+func (r *FastImportRepository) GetTransactions() ([]*Transaction, map[FileID]FilePath, error) {
+	f, err := os.Open(r.path)
+	if err != nil {
+		return nil, nil, fmt.Errorf("open fast-import stream: %w", err)
+	}
+	defer f.Close()
+
+	commits, err := readFastImport(bufio.NewReader(f))
+	if err != nil {
+		return nil, nil, fmt.Errorf("read fast-import stream: %w", err)
+	}
+
+	history := newHistoryBuilder(func(name string) (string, bool) {
+		return name, name != ""
+	})
+	// branches may be interleaved in a stream, so the history is built from the newest commit as in the other backends.
+	// Parents precede their children in a stream, so commits at the same time are taken from its end.
+	slices.Reverse(commits)
+	slices.SortStableFunc(commits, func(a, b *fastImportCommit) int {
+		return b.time.Compare(a.time)
+	})
+
+	var transactions []*Transaction
+	for _, commit := range commits {
+		tx := &Transaction{
+			ID:        commit.id,
+			Time:      commit.time,
+			Author:    commit.author,
+			Committer: commit.committer,
+			Message:   commit.message,
+			Files:     collection.NewSet[FileID](),
+		}
+		for _, change := range commit.changes {
+			history.add(tx, change.from, change.to)
+		}
+
+		if tx.Files.Len() > 0 {
+			transactions = append(transactions, tx)
+			if r.transactionLimit != 0 && len(transactions) >= r.transactionLimit {
+				break
+			}
+		}
+	}
+
+	return transactions, history.fileMap, nil
+}
+
+type fastImportCommit struct {
+	id        string
+	time      time.Time
+	author    string
+	committer string
+	message   string
+	changes   []fileChange
+}
+
+// fastImportTree maps the paths of files in a branch to their contents.
+type fastImportTree map[string]string
+
+// fastImportReader reads a stream line by line, with a line of lookahead.
+type fastImportReader struct {
+	reader *bufio.Reader
+	line   string
+	peeked bool
+}
+
+func (r *fastImportReader) next() (string, error) {
+	if r.peeked {
+		r.peeked = false
+		return r.line, nil
+	}
+
+	line, err := r.reader.ReadString('\n')
+	if errors.Is(err, io.EOF) && line != "" {
+		err = nil
+	}
+	if err != nil {
+		return "", err
+	}
+
+	return strings.TrimSuffix(line, "\n"), nil
+}
+
+func (r *fastImportReader) unread(line string) {
+	r.line = line
+	r.peeked = true
+}
+
+// data reads the argument of a data command, in either the exact byte count or the delimited format.
+func (r *fastImportReader) data(line string) (string, error) {
+	arg, ok := strings.CutPrefix(line, "data ")
+	if !ok {
+		return "", fmt.Errorf("expected data: %q", line)
+	}
+
+	if delimiter, ok := strings.CutPrefix(arg, "<<"); ok {
+		var sb strings.Builder
+		for {
+			line, err := r.next()
+			if err != nil {
+				return "", fmt.Errorf("read delimited data: %w", err)
+			}
+			if line == delimiter {
+				return sb.String(), nil
+			}
+			sb.WriteString(line + "\n")
+		}
+	}
+
+	length, err := strconv.Atoi(arg)
+	if err != nil {
+		return "", fmt.Errorf("parse data length: %w", err)
+	}
+	data := make([]byte, length)
+	if _, err := io.ReadFull(r.reader, data); err != nil {
+		return "", fmt.Errorf("read data: %w", err)
+	}
+	// an optional LF follows the data
+	if b, err := r.reader.Peek(1); err == nil && b[0] == '\n' {
+		_, _ = r.reader.Discard(1)
+	}
+
+	return string(data), nil
+}
+
+// fastImportState tracks the trees of refs and commits while reading a stream.
+type fastImportState struct {
+	// trees are the trees of the refs, which are changed in place by the commits following their tips
+	trees map[string]fastImportTree
+	// tips are the marks of the latest commits of the refs
+	tips map[string]string
+	// commits are the parents and the changed contents of commits by their marks,
+	// to reconstruct the trees of commits which are no longer tips
+	commits map[string]*fastImportDelta
+}
+
+type fastImportDelta struct {
+	parent string
+	// contents are the contents of changed files, which are empty for deleted files
+	contents map[string]string
+}
+
+// tree returns a copy of the tree of a commit or a ref given by a from or reset command.
+func (s *fastImportState) tree(commitish string) fastImportTree {
+	if tree, ok := s.trees[commitish]; ok {
+		return maps.Clone(tree)
+	}
+	for ref, tip := range s.tips {
+		if tip == commitish {
+			return maps.Clone(s.trees[ref])
+		}
+	}
+
+	var deltas []*fastImportDelta
+	for mark := commitish; mark != ""; {
+		delta, ok := s.commits[mark]
+		if !ok {
+			slog.Debug("unknown commit, starting from an empty tree",
+				slog.String("commit", mark),
+			)
+			break
+		}
+		deltas = append(deltas, delta)
+		mark = delta.parent
+	}
+
+	tree := fastImportTree{}
+	for _, delta := range slices.Backward(deltas) {
+		for path, content := range delta.contents {
+			if content == "" {
+				delete(tree, path)
+			} else {
+				tree[path] = content
+			}
+		}
+	}
+
+	return tree
+}
+
+// readFastImport reads the commits of a stream in the order of the stream.
+func readFastImport(reader *bufio.Reader) ([]*fastImportCommit, error) {
+	r := &fastImportReader{reader: reader}
+	state := &fastImportState{
+		trees:   make(map[string]fastImportTree),
+		tips:    make(map[string]string),
+		commits: make(map[string]*fastImportDelta),
+	}
+
+	var commits []*fastImportCommit
+	for {
+		line, err := r.next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		command, arg, _ := strings.Cut(line, " ")
+		switch command {
+		case "commit":
+			commit, err := r.commit(arg, state)
+			if err != nil {
+				return nil, fmt.Errorf("read commit of %s: %w", arg, err)
+			}
+			commits = append(commits, commit)
+		case "reset":
+			ref := arg
+			line, err := r.next()
+			if err != nil && !errors.Is(err, io.EOF) {
+				return nil, err
+			}
+			if from, ok := strings.CutPrefix(line, "from "); ok {
+				state.trees[ref] = state.tree(from)
+				state.tips[ref] = from
+				if tip, ok := state.tips[from]; ok {
+					state.tips[ref] = tip
+				}
+			} else {
+				delete(state.trees, ref)
+				delete(state.tips, ref)
+				if err == nil {
+					r.unread(line)
+				}
+			}
+		case "data":
+			// data of blob, tag and other commands
+			if _, err := r.data(line); err != nil {
+				return nil, err
+			}
+		}
+		// other commands and their lines other than data are skipped
+	}
+
+	return commits, nil
+}
+
+func (r *fastImportReader) commit(ref string, state *fastImportState) (*fastImportCommit, error) {
+	commit := &fastImportCommit{}
+	var mark string
+	parent := state.tips[ref]
+	tree, known := state.trees[ref]
+	if !known {
+		tree = fastImportTree{}
+	}
+
+	// the contents of touched files before the commit, and renames in the commit
+	before := make(map[string]string)
+	touch := func(path string) {
+		if _, ok := before[path]; !ok {
+			before[path] = tree[path]
+		}
+	}
+	renames := make(map[string]string)
+	// files returns the file at path or the files under the directory at path
+	files := func(path string) []string {
+		if _, ok := tree[path]; ok {
+			return []string{path}
+		}
+		var files []string
+		for file := range tree {
+			if strings.HasPrefix(file, path+"/") || path == "" {
+				files = append(files, file)
+			}
+		}
+		slices.Sort(files)
+		return files
+	}
+
+loop:
+	for {
+		line, err := r.next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		command, arg, _ := strings.Cut(line, " ")
+		switch command {
+		case "mark":
+			mark = arg
+		case "original-oid":
+			commit.id = arg
+		case "encoding", "merge":
+		case "author", "committer":
+			email, t := parseFastImportIdent(arg)
+			if command == "author" || commit.author == "" {
+				commit.author = email
+			}
+			if command == "committer" {
+				commit.committer = email
+				commit.time = t
+			}
+		case "gpgsig":
+			// gpgsig <algorithm> [<format>] is followed by the signature, which does not change files
+			dataLine, err := r.next()
+			if err != nil {
+				return nil, err
+			}
+			if _, err := r.data(dataLine); err != nil {
+				return nil, err
+			}
+		case "data":
+			commit.message, err = r.data(line)
+			if err != nil {
+				return nil, err
+			}
+		case "from":
+			// the tree of the ref is changed in place when the commit follows its tip
+			if !known || parent != arg {
+				tree = state.tree(arg)
+			}
+			parent = arg
+			if tip, ok := state.tips[arg]; ok {
+				parent = tip
+			}
+		case "M":
+			// M <mode> <dataref> <path>
+			fields := strings.SplitN(arg, " ", 3)
+			if len(fields) < 3 {
+				return nil, fmt.Errorf("malformed filemodify: %q", line)
+			}
+			path, err := parseFastImportPath(fields[2])
+			if err != nil {
+				return nil, err
+			}
+
+			content := fields[1]
+			if content == "inline" {
+				dataLine, err := r.next()
+				if err != nil {
+					return nil, err
+				}
+				data, err := r.data(dataLine)
+				if err != nil {
+					return nil, err
+				}
+				hash := fnv.New64a()
+				_, _ = hash.Write([]byte(data))
+				content = "inline:" + strconv.FormatUint(hash.Sum64(), 16)
+			}
+
+			touch(path)
+			tree[path] = content
+		case "D":
+			path, err := parseFastImportPath(arg)
+			if err != nil {
+				return nil, err
+			}
+			for _, file := range files(path) {
+				touch(file)
+				delete(tree, file)
+			}
+		case "R", "C":
+			src, rest, err := cutFastImportPath(arg)
+			if err != nil {
+				return nil, err
+			}
+			dst, err := parseFastImportPath(rest)
+			if err != nil {
+				return nil, err
+			}
+
+			for _, file := range files(src) {
+				target := dst + strings.TrimPrefix(file, src)
+				touch(file)
+				touch(target)
+				tree[target] = tree[file]
+				if command == "R" {
+					delete(tree, file)
+					renames[file] = target
+				}
+			}
+		case "deleteall":
+			for file := range tree {
+				touch(file)
+			}
+			clear(tree)
+		case "N":
+			// notes do not change files
+			if strings.HasPrefix(arg, "inline ") {
+				dataLine, err := r.next()
+				if err != nil {
+					return nil, err
+				}
+				if _, err := r.data(dataLine); err != nil {
+					return nil, err
+				}
+			}
+		case "":
+			// a blank line may end the commit
+		default:
+			r.unread(line)
+			break loop
+		}
+	}
+
+	state.trees[ref] = tree
+	if mark != "" {
+		state.tips[ref] = mark
+	} else {
+		delete(state.tips, ref)
+	}
+	if commit.id == "" {
+		// commits without original ids are identified by their marks
+		commit.id = mark
+	}
+
+	delta := &fastImportDelta{
+		parent:   parent,
+		contents: make(map[string]string, len(before)),
+	}
+	for path := range before {
+		delta.contents[path] = tree[path]
+	}
+	if mark != "" {
+		state.commits[mark] = delta
+	}
+
+	renamedTo := make(map[string]string, len(renames))
+	renamedFrom := collection.NewSet[string]()
+	for from, to := range renames {
+		// a file renamed and then restored is not a rename
+		if _, ok := tree[from]; !ok && tree[to] != "" && before[to] == "" {
+			renamedTo[to] = from
+			renamedFrom.Add(from)
+		}
+	}
+
+	paths := slices.Sorted(maps.Keys(before))
+	for _, path := range paths {
+		previous, current := before[path], tree[path]
+		switch {
+		case previous == current:
+		case current == "":
+			if !renamedFrom.Contains(path) {
+				commit.changes = append(commit.changes, fileChange{from: path})
+			}
+		case previous == "":
+			if from, ok := renamedTo[path]; ok {
+				commit.changes = append(commit.changes, fileChange{from: from, to: path})
+			} else {
+				commit.changes = append(commit.changes, fileChange{to: path})
+			}
+		default:
+			commit.changes = append(commit.changes, fileChange{from: path, to: path})
+		}
+	}
+
+	return commit, nil
+}
+
+// parseFastImportIdent parses "<name> <<email>> <time> <offset>" into the email and time.
+func parseFastImportIdent(ident string) (string, time.Time) {
+	start := strings.Index(ident, "<")
+	end := strings.LastIndex(ident, ">")
+	if start < 0 || end < start {
+		return "", time.Time{}
+	}
+	email := ident[start+1 : end]
+
+	fields := strings.Fields(ident[end+1:])
+	if len(fields) == 0 {
+		return email, time.Time{}
+	}
+	seconds, err := strconv.ParseInt(fields[0], 10, 64)
+	if err != nil {
+		return email, time.Time{}
+	}
+
+	return email, time.Unix(seconds, 0)
+}
+
+// parseFastImportPath parses a path extending to the end of s, which is C-style quoted if it starts with a double quote.
+func parseFastImportPath(s string) (string, error) {
+	if !strings.HasPrefix(s, `"`) {
+		return s, nil
+	}
+
+	path, err := strconv.Unquote(s)
+	if err != nil {
+		return "", fmt.Errorf("unquote path %s: %w", s, err)
+	}
+
+	return path, nil
+}
+
+// cutFastImportPath cuts a path followed by another argument from s.
+// An unquoted path extends to the first space.
+func cutFastImportPath(s string) (string, string, error) {
+	if !strings.HasPrefix(s, `"`) {
+		path, rest, ok := strings.Cut(s, " ")
+		if !ok {
+			return "", "", fmt.Errorf("missing argument after path: %s", s)
+		}
+		return path, rest, nil
+	}
+
+	for i := 1; i < len(s); i++ {
+		switch s[i] {
+		case '\\':
+			i++
+		case '"':
+			path, err := strconv.Unquote(s[:i+1])
+			if err != nil {
+				return "", "", fmt.Errorf("unquote path %s: %w", s[:i+1], err)
+			}
+			return path, strings.TrimPrefix(s[i+1:], " "), nil
+		}
+	}
+
+	return "", "", fmt.Errorf("unterminated quoted path: %s", s)
+}
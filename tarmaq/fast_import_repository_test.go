@@ -0,0 +1,231 @@
+package tarmaq
+
+import (
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFastImportRepository_GetTransactions(t *testing.T) {
+	t.Parallel()
+
+	stream := `blob
+mark :1
+data 6
+hello
+
+blob
+mark :2
+data <<EOF
+world
+EOF
+
+reset refs/heads/main
+commit refs/heads/main
+mark :10
+author Alice <alice@example.com> 1704067200 +0900
+committer Bob <bob@example.com> 1704067300 +0000
+data 8
+Add a, b
+M 100644 :1 src/a.go
+M 100644 :2 "src/b c.go"
+
+commit refs/heads/main
+mark :11
+author Alice <alice@example.com> 1704153600 +0000
+committer Alice <alice@example.com> 1704153600 +0000
+data 11
+Rename a.go
+from :10
+R src/a.go src/x.go
+M 100644 inline src/x.go
+data 7
+edited
+
+commit refs/heads/main
+mark :12
+author Alice <alice@example.com> 1704240000 +0000
+committer Alice <alice@example.com> 1704240000 +0000
+data 8
+Snapshot
+from :11
+deleteall
+M 100644 :2 "src/b c.go"
+M 100644 :1 src/x.go
+
+reset refs/heads/dev
+from :11
+
+commit refs/heads/dev
+mark :13
+author Carol <carol@example.com> 1704326400 +0000
+committer Carol <carol@example.com> 1704326400 +0000
+data 9
+Move src/
+D "src/b c.go"
+R src lib
+
+progress done
+`
+
+	path := filepath.Join(t.TempDir(), "history.fi")
+	if err := os.WriteFile(path, []byte(stream), 0o600); err != nil {
+		t.Fatalf("failed to write stream: %v", err)
+	}
+
+	r, err := NewFastImportRepository(path, 0)
+	assert.NoError(t, err)
+
+	gotTrans, gotFileMap, err := r.GetTransactions()
+	assert.NoError(t, err)
+
+	// the rename on the dev branch applies to the main branch too, as with the commits of several refs in a git repository
+	wantTrans := []*Transaction{
+		{
+			ID:      ":13",
+			Time:    time.Unix(1704326400, 0),
+			Author:  "carol@example.com",
+			Message: "Move src/",
+			Files:   makeFileSet(FileID(0), FileID(1)),
+			Deleted: makeFileSet(FileID(1)),
+		},
+		{
+			ID:      ":12",
+			Time:    time.Unix(1704240000, 0),
+			Author:  "alice@example.com",
+			Message: "Snapshot",
+			Files:   makeFileSet(FileID(0)),
+		},
+		{
+			ID:      ":11",
+			Time:    time.Unix(1704153600, 0),
+			Author:  "alice@example.com",
+			Message: "Rename a.go",
+			Files:   makeFileSet(FileID(0)),
+		},
+		{
+			ID:      ":10",
+			Time:    time.Unix(1704067300, 0),
+			Author:  "alice@example.com",
+			Message: "Add a, b",
+			Files:   makeFileSet(FileID(0), FileID(1)),
+		},
+	}
+	wantFileMap := map[FileID]FilePath{
+		FileID(0): NewFilePath("lib/x.go"),
+		FileID(1): NewFilePath("src/b c.go"),
+	}
+
+	assert.Equal(t, len(wantTrans), len(gotTrans), "Number of transactions does not match")
+	for i := 0; i < len(wantTrans) && i < len(gotTrans); i++ {
+		assert.Equal(t, wantTrans[i].ID, gotTrans[i].ID)
+		assert.True(t, wantTrans[i].Time.Equal(gotTrans[i].Time), "Time of transaction %d", i)
+		assert.Equal(t, wantTrans[i].Author, gotTrans[i].Author)
+		assert.Equal(t, wantTrans[i].Message, gotTrans[i].Message)
+		assertSetEqual(t, wantTrans[i].Files, gotTrans[i].Files, "Files of transaction %d", i)
+		assertSetEqual(t, wantTrans[i].Deleted, gotTrans[i].Deleted, "Deleted files of transaction %d", i)
+	}
+	assert.Equal(t, wantFileMap, gotFileMap, "File map does not match")
+}
+
+func TestFastImportRepository_SignedCommit(t *testing.T) {
+	t.Parallel()
+
+	stream := `commit refs/heads/main
+mark :1
+author Alice <alice@example.com> 1704067200 +0000
+committer Alice <alice@example.com> 1704067200 +0000
+gpgsig sha1 openpgp
+data 76
+-----BEGIN PGP SIGNATURE-----
+
+iQEzBAABCAAdFiEE
+-----END PGP SIGNATURE-----
+data 6
+Signed
+M 100644 inline a.go
+data 2
+a
+M 100644 inline b.go
+data 2
+b
+
+`
+
+	path := filepath.Join(t.TempDir(), "history.fi")
+	if err := os.WriteFile(path, []byte(stream), 0o600); err != nil {
+		t.Fatalf("failed to write stream: %v", err)
+	}
+
+	r, err := NewFastImportRepository(path, 0)
+	assert.NoError(t, err)
+
+	gotTrans, gotFileMap, err := r.GetTransactions()
+	assert.NoError(t, err)
+
+	if assert.Len(t, gotTrans, 1) {
+		assert.Equal(t, "Signed", gotTrans[0].Message)
+		assertSetEqual(t, makeFileSet(FileID(0), FileID(1)), gotTrans[0].Files, "Files of the signed commit")
+	}
+	assert.ElementsMatch(t, []FilePath{"a.go", "b.go"}, slices.Collect(maps.Values(gotFileMap)))
+}
+
+func TestFastImportRepository_InterleavedBranches(t *testing.T) {
+	t.Parallel()
+
+	// the commit of dev deleting a.go is older than the last commit of main changing it, but follows it in the stream
+	stream := `commit refs/heads/main
+mark :1
+author Alice <alice@example.com> 1704067200 +0000
+committer Alice <alice@example.com> 1704067200 +0000
+data 3
+Add
+M 100644 inline a.go
+data 2
+a
+
+commit refs/heads/main
+mark :2
+author Alice <alice@example.com> 1704240000 +0000
+committer Alice <alice@example.com> 1704240000 +0000
+data 4
+Edit
+from :1
+M 100644 inline a.go
+data 3
+aa
+
+commit refs/heads/dev
+mark :3
+author Bob <bob@example.com> 1704153600 +0000
+committer Bob <bob@example.com> 1704153600 +0000
+data 6
+Delete
+from :1
+D a.go
+
+`
+
+	path := filepath.Join(t.TempDir(), "history.fi")
+	if err := os.WriteFile(path, []byte(stream), 0o600); err != nil {
+		t.Fatalf("failed to write stream: %v", err)
+	}
+
+	r, err := NewFastImportRepository(path, 0)
+	assert.NoError(t, err)
+
+	gotTrans, _, err := r.GetTransactions()
+	assert.NoError(t, err)
+
+	ids := make([]string, 0, len(gotTrans))
+	for _, tx := range gotTrans {
+		ids = append(ids, tx.ID)
+	}
+	assert.Equal(t, []string{":2", ":3", ":1"}, ids)
+	assert.Equal(t, 0, RemovedFiles(gotTrans).Len())
+}
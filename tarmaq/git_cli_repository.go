@@ -0,0 +1,491 @@
+package tarmaq
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/mazrean/mcp-tarmaq/pkg/collection"
+)
+
+var (
+	_ Repository       = &GitCLIRepository{}
+	_ RefSelector      = &GitCLIRepository{}
+	_ HeadResolver     = &GitCLIRepository{}
+	_ ShallowReporter  = &GitCLIRepository{}
+	_ WorktreeReporter = &GitCLIRepository{}
+	_ AuthorReporter   = &GitCLIRepository{}
+)
+
+// GitCLIRepository mines the history by parsing the output of the git command.
+// Unlike GitRepository, it only reads commits and trees, so it is fast on large packfiles and works with partial clones.
+type GitCLIRepository struct {
+	gitConfig
+	// dir is the directory the git command runs in.
+	dir string
+	// root is the absolute path of the working tree. It is empty for bare repositories.
+	root string
+}
+
+// NewGitCLIRepository opens the repository containing repoPath with the git command.
+// If repoPath is a subdirectory of the working tree, mining is limited to it.
+func NewGitCLIRepository(repoPath string, transactionLimit int, options ...GitRepositoryOption) (*GitCLIRepository, error) {
+	r := &GitCLIRepository{
+		gitConfig: gitConfig{
+			transactionLimit: transactionLimit,
+			renameScore:      DefaultRenameScore,
+		},
+		dir: repoPath,
+	}
+
+	bare, err := r.git(context.Background(), "rev-parse", "--is-bare-repository")
+	if err != nil {
+		return nil, err
+	}
+	if strings.TrimSpace(bare) == "false" {
+		out, err := r.git(context.Background(), "rev-parse", "--show-toplevel", "--show-prefix")
+		if err != nil {
+			return nil, err
+		}
+
+		root, prefix, _ := strings.Cut(strings.TrimSuffix(out, "\n"), "\n")
+		r.root = filepath.FromSlash(root)
+		WithSubdirectory(prefix)(&r.gitConfig)
+	}
+
+	for _, option := range options {
+		option(&r.gitConfig)
+	}
+
+	return r, nil
+}
+
+func (r *GitCLIRepository) git(ctx context.Context, args ...string) (string, error) {
+	var stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, "git", append([]string{"-C", r.dir}, args...)...)
+	cmd.Stderr = &stderr
+
+	out, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("git %s: %w: %s", args[0], err, strings.TrimSpace(stderr.String()))
+	}
+
+	return string(out), nil
+}
+
+// Submodules returns the absolute paths of the checked out submodules under the subdirectory,
+// keyed by their paths relative to the subdirectory.
+func (r *GitCLIRepository) Submodules() (map[string]string, error) {
+	paths := map[string]string{}
+	if r.root == "" {
+		return paths, nil
+	}
+	if _, err := os.Stat(filepath.Join(r.root, ".gitmodules")); errors.Is(err, os.ErrNotExist) {
+		return paths, nil
+	}
+
+	out, err := r.git(context.Background(), "config", "--file", filepath.Join(r.root, ".gitmodules"), "--get-regexp", `^submodule\..*\.path$`)
+	if err != nil {
+		return nil, fmt.Errorf("get submodules: %w", err)
+	}
+
+	for _, line := range strings.Split(strings.TrimSpace(out), "\n") {
+		_, subPath, ok := strings.Cut(line, " ")
+		if !ok {
+			continue
+		}
+		subPath = path.Clean(subPath)
+
+		rel, ok := r.scope(subPath)
+		if !ok {
+			continue
+		}
+
+		absPath := filepath.Join(r.root, filepath.FromSlash(subPath))
+		if _, err := os.Stat(filepath.Join(absPath, ".git")); err != nil {
+			slog.Warn("submodule is not checked out",
+				slog.String("submodule", subPath),
+			)
+			continue
+		}
+		paths[rel] = absPath
+	}
+
+	return paths, nil
+}
+
+func (r *GitCLIRepository) ChangedFiles() ([]FilePath, error) {
+	if r.root == "" {
+		return nil, nil
+	}
+
+	out, err := r.git(context.Background(), "status", "--porcelain", "-z", "--untracked-files=all")
+	if err != nil {
+		return nil, err
+	}
+
+	var files []FilePath
+	for _, name := range parseGitStatus(out) {
+		if rel, ok := r.scope(name); ok {
+			files = append(files, NewFilePath(rel))
+		}
+	}
+
+	return files, nil
+}
+
+func (r *GitCLIRepository) SelectRefs(refs []string, all bool) Repository {
+	selected := *r
+	selected.refs = refs
+	selected.allRefs = all
+
+	return &selected
+}
+
+// Head returns the hash of HEAD, or the hashes of the selected refs separated by commas.
+func (r *GitCLIRepository) Head() (string, error) {
+	args := []string{"rev-parse"}
+	if r.allRefs {
+		args = append(args, "--branches", "--remotes")
+	}
+	args = append(args, r.refs...)
+	if len(r.refs) == 0 && !r.allRefs {
+		args = append(args, "HEAD")
+	}
+
+	out, err := r.git(context.Background(), args...)
+	if err != nil {
+		return "", err
+	}
+
+	return strings.Join(strings.Fields(out), ","), nil
+}
+
+func (r *GitCLIRepository) Identity() (string, error) {
+	out, err := r.git(context.Background(), "config", "--default", "", "user.email")
+	if err != nil {
+		return "", err
+	}
+
+	return strings.TrimSpace(out), nil
+}
+
+func (r *GitCLIRepository) Mailmap() (*Mailmap, error) {
+	out, err := r.git(context.Background(), "ls-tree", "--full-tree", "--name-only", "HEAD", ".mailmap")
+	if err != nil || strings.TrimSpace(out) == "" {
+		// repositories without commits have no HEAD
+		return NewMailmap(), nil
+	}
+
+	out, err = r.git(context.Background(), "cat-file", "blob", "HEAD:.mailmap")
+	if err != nil {
+		return nil, err
+	}
+
+	return ParseMailmap(strings.NewReader(out))
+}
+
+func (r *GitCLIRepository) Depth() (int, bool, error) {
+	out, err := r.git(context.Background(), "rev-parse", "--is-shallow-repository")
+	if err != nil {
+		return 0, false, err
+	}
+	if strings.TrimSpace(out) != "true" {
+		return 0, false, nil
+	}
+
+	shallow, err := r.shallowCommits()
+	if err != nil {
+		return 0, false, fmt.Errorf("get shallow commits: %w", err)
+	}
+
+	args := []string{"rev-list"}
+	if r.allRefs {
+		args = append(args, "--branches", "--remotes")
+	}
+	args = append(args, r.refs...)
+	if len(r.refs) == 0 && !r.allRefs {
+		args = append(args, "HEAD")
+	}
+	out, err = r.git(context.Background(), args...)
+	if err != nil {
+		return 0, false, err
+	}
+
+	// the boundary commits are not counted, since GetTransactions skips them
+	depth := 0
+	for _, hash := range strings.Fields(out) {
+		if !shallow.Contains(hash) {
+			depth++
+		}
+	}
+
+	return depth, true, nil
+}
+
+func (r *GitCLIRepository) Deepen(commits int) error {
+	return deepen(r.dir, commits)
+}
+
+// shallowCommits returns the boundary commits of a shallow clone.
+func (r *GitCLIRepository) shallowCommits() (collection.Set[string], error) {
+	out, err := r.git(context.Background(), "rev-parse", "--git-path", "shallow")
+	if err != nil {
+		return nil, err
+	}
+
+	shallowPath := filepath.FromSlash(strings.TrimSpace(out))
+	if !filepath.IsAbs(shallowPath) {
+		shallowPath = filepath.Join(r.dir, shallowPath)
+	}
+
+	content, err := os.ReadFile(shallowPath)
+	if errors.Is(err, os.ErrNotExist) {
+		return collection.NewSet[string](), nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("read shallow file: %w", err)
+	}
+
+	return collection.NewSet(strings.Fields(string(content))...), nil
+}
+
+// gitLogFormat starts each commit with a record separator, followed by NUL separated fields.
+const gitLogFormat = "--format=%x1e%H%x00%ct%x00%ae%x00%ce%x00%B"
+
+func (r *GitCLIRepository) logArgs() []string {
+	args := []string{"log", "-z", "--raw", "--no-abbrev", "--no-color", "--diff-merges=first-parent", gitLogFormat}
+	if r.collectChurn {
+		args = append(args, "--numstat")
+	}
+
+	switch {
+	case r.renameScore == 0:
+		args = append(args, "--no-renames")
+	case r.renameScore >= 100:
+		args = append(args, "-M100%")
+	default:
+		args = append(args, fmt.Sprintf("-M%d%%", r.renameScore))
+	}
+
+	if r.allRefs {
+		args = append(args, "--branches", "--remotes")
+	}
+	args = append(args, r.refs...)
+	if len(r.refs) == 0 && !r.allRefs {
+		args = append(args, "HEAD")
+	}
+
+	return append(args, "--")
+}
+
+func (r *GitCLIRepository) GetTransactions() ([]*Transaction, map[FileID]FilePath, error) {
+	shallow, err := r.shallowCommits()
+	if err != nil {
+		return nil, nil, fmt.Errorf("get shallow commits: %w", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, "git", append([]string{"-C", r.dir}, r.logArgs()...)...)
+	cmd.Stderr = &stderr
+
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, nil, fmt.Errorf("get stdout of git log: %w", err)
+	}
+	if err := cmd.Start(); err != nil {
+		return nil, nil, fmt.Errorf("start git log: %w", err)
+	}
+
+	transactions, fileMap, err := r.parseLog(bufio.NewReader(stdout), shallow)
+	if err != nil {
+		cancel()
+		_ = cmd.Wait()
+		return nil, nil, err
+	}
+
+	// git is killed when the transaction limit is reached
+	if err := cmd.Wait(); err != nil && ctx.Err() == nil {
+		return nil, nil, fmt.Errorf("git log: %w: %s", err, strings.TrimSpace(stderr.String()))
+	}
+
+	return transactions, fileMap, nil
+}
+
+// parseLog parses the output of git log, skipping the shallow commits whose diffs are against the empty tree.
+func (r *GitCLIRepository) parseLog(reader *bufio.Reader, shallow collection.Set[string]) ([]*Transaction, map[FileID]FilePath, error) {
+	history := newHistoryBuilder(r.scope)
+	var transactions []*Transaction
+
+	for {
+		record, err := reader.ReadBytes('\x1e')
+		if record = bytes.TrimRight(record, "\x1e"); len(record) > 0 {
+			commit, parseErr := parseGitLogRecord(record)
+			if parseErr != nil {
+				return nil, nil, parseErr
+			}
+
+			if shallow.Contains(commit.id) {
+				slog.Debug("skip shallow commit",
+					slog.String("commit", commit.id),
+				)
+			} else if tx := r.transaction(history, commit); tx.Files.Len() > 0 {
+				transactions = append(transactions, tx)
+				if r.transactionLimit != 0 && len(transactions) >= r.transactionLimit {
+					break
+				}
+			}
+		}
+
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, nil, fmt.Errorf("read git log: %w", err)
+		}
+	}
+
+	return transactions, history.fileMap, nil
+}
+
+func (r *GitCLIRepository) transaction(history *historyBuilder, commit *gitLogCommit) *Transaction {
+	tx := &Transaction{
+		ID:        commit.id,
+		Time:      commit.time,
+		Author:    commit.author,
+		Committer: commit.committer,
+		Message:   commit.message,
+		Files:     collection.NewSet[FileID](),
+	}
+	if r.collectChurn {
+		tx.Churn = make(map[FileID]uint64)
+	}
+	for _, change := range commit.changes {
+		fileID, ok := history.add(tx, change.from, change.to)
+		if !ok {
+			continue
+		}
+
+		if tx.Churn != nil {
+			name := change.to
+			if name == "" {
+				name = change.from
+			}
+			tx.Churn[fileID] += commit.churn[name]
+		}
+	}
+
+	return tx
+}
+
+type gitLogCommit struct {
+	id        string
+	time      time.Time
+	author    string
+	committer string
+	message   string
+	changes   []fileChange
+	// churn is the number of changed lines per path, collected with --numstat.
+	churn map[string]uint64
+}
+
+// parseGitLogRecord parses a commit formatted with gitLogFormat and followed by --raw and --numstat entries.
+func parseGitLogRecord(record []byte) (*gitLogCommit, error) {
+	fields := strings.Split(string(record), "\x00")
+	if len(fields) < 5 {
+		return nil, fmt.Errorf("malformed git log record: %q", record)
+	}
+
+	timestamp, err := strconv.ParseInt(fields[1], 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("parse commit time of %s: %w", fields[0], err)
+	}
+
+	commit := &gitLogCommit{
+		id:        fields[0],
+		time:      time.Unix(timestamp, 0),
+		author:    fields[2],
+		committer: fields[3],
+		message:   fields[4],
+		churn:     make(map[string]uint64),
+	}
+
+	entries := fields[5:]
+	if len(entries) > 0 {
+		// the diff is separated from the message by a newline
+		entries[0] = strings.TrimPrefix(entries[0], "\n")
+	}
+	for i := 0; i < len(entries); {
+		entry := entries[i]
+		switch {
+		case entry == "":
+			i++
+		case entry[0] == ':':
+			// :<src mode> <dst mode> <src hash> <dst hash> <status>\0<path>[\0<path>]
+			status := strings.Fields(entry)
+			if len(status) < 5 || i+1 >= len(entries) {
+				return nil, fmt.Errorf("malformed raw entry of %s: %q", commit.id, entry)
+			}
+
+			switch status[4][0] {
+			case 'R', 'C':
+				if i+2 >= len(entries) {
+					return nil, fmt.Errorf("malformed raw entry of %s: %q", commit.id, entry)
+				}
+				change := fileChange{from: entries[i+1], to: entries[i+2]}
+				if status[4][0] == 'C' {
+					// the source of a copy is unchanged
+					change.from = ""
+				}
+				commit.changes = append(commit.changes, change)
+				i += 3
+			case 'A':
+				commit.changes = append(commit.changes, fileChange{to: entries[i+1]})
+				i += 2
+			case 'D':
+				commit.changes = append(commit.changes, fileChange{from: entries[i+1]})
+				i += 2
+			default:
+				commit.changes = append(commit.changes, fileChange{from: entries[i+1], to: entries[i+1]})
+				i += 2
+			}
+		default:
+			// <added>\t<deleted>\t<path>, or <added>\t<deleted>\t\0<from>\0<to> for renames
+			stat := strings.SplitN(entry, "\t", 3)
+			if len(stat) < 3 {
+				return nil, fmt.Errorf("malformed numstat entry of %s: %q", commit.id, entry)
+			}
+
+			name := stat[2]
+			i++
+			if name == "" {
+				if i+1 >= len(entries) {
+					return nil, fmt.Errorf("malformed numstat entry of %s: %q", commit.id, entry)
+				}
+				name = entries[i+1]
+				i += 2
+			}
+
+			// binary files are reported as "-"
+			added, _ := strconv.ParseUint(stat[0], 10, 64)
+			deleted, _ := strconv.ParseUint(stat[1], 10, 64)
+			commit.churn[name] += added + deleted
+		}
+	}
+
+	return commit, nil
+}
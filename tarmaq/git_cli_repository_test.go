@@ -0,0 +1,242 @@
+package tarmaq
+
+import (
+	"bufio"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/mazrean/mcp-tarmaq/pkg/collection"
+)
+
+func TestParseGitLogRecord(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		record  string
+		want    *gitLogCommit
+		wantErr bool
+	}{
+		{
+			name:   "Commit without changes",
+			record: "abc\x001700000000\x00test@example.com\x00committer@example.com\x00empty\n",
+			want: &gitLogCommit{
+				id:        "abc",
+				time:      time.Unix(1700000000, 0),
+				author:    "test@example.com",
+				committer: "committer@example.com",
+				message:   "empty\n",
+				churn:     map[string]uint64{},
+			},
+		},
+		{
+			name: "Raw entries",
+			record: "abc\x001700000000\x00test@example.com\x00committer@example.com\x00message\n\x00" +
+				"\n:000000 100644 0000 1111 A\x00added.go\x00" +
+				":100644 100644 1111 2222 M\x00modified.go\x00" +
+				":100644 000000 1111 0000 D\x00deleted.go\x00" +
+				":100644 100644 1111 2222 R086\x00old.go\x00new.go\x00" +
+				":100644 100644 1111 1111 C100\x00src.go\x00copy.go\x00",
+			want: &gitLogCommit{
+				id:        "abc",
+				time:      time.Unix(1700000000, 0),
+				author:    "test@example.com",
+				committer: "committer@example.com",
+				message:   "message\n",
+				changes: []fileChange{
+					{to: "added.go"},
+					{from: "modified.go", to: "modified.go"},
+					{from: "deleted.go"},
+					{from: "old.go", to: "new.go"},
+					{to: "copy.go"},
+				},
+				churn: map[string]uint64{},
+			},
+		},
+		{
+			name: "Numstat entries",
+			record: "abc\x001700000000\x00test@example.com\x00committer@example.com\x00message\n\x00" +
+				"\n:100644 100644 1111 2222 M\x00modified.go\x00" +
+				":100644 100644 1111 2222 R090\x00old.go\x00new.go\x00" +
+				":100644 100644 1111 2222 M\x00image.png\x00" +
+				"3\t2\tmodified.go\x00" +
+				"1\t1\t\x00old.go\x00new.go\x00" +
+				"-\t-\timage.png\x00",
+			want: &gitLogCommit{
+				id:        "abc",
+				time:      time.Unix(1700000000, 0),
+				author:    "test@example.com",
+				committer: "committer@example.com",
+				message:   "message\n",
+				changes: []fileChange{
+					{from: "modified.go", to: "modified.go"},
+					{from: "old.go", to: "new.go"},
+					{from: "image.png", to: "image.png"},
+				},
+				churn: map[string]uint64{
+					"modified.go": 5,
+					"new.go":      2,
+					"image.png":   0,
+				},
+			},
+		},
+		{
+			name:    "Missing fields",
+			record:  "abc\x001700000000",
+			wantErr: true,
+		},
+		{
+			name:    "Truncated rename",
+			record:  "abc\x001700000000\x00test@example.com\x00committer@example.com\x00message\n\x00\n:100644 100644 1111 2222 R086\x00old.go",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := parseGitLogRecord([]byte(tt.record))
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestGitCLIRepository_parseLog(t *testing.T) {
+	t.Parallel()
+
+	// newest first, as git log outputs
+	log := "\x1ec3\x001700000200\x00test@example.com\x00committer@example.com\x00Update b.go\n\x00" +
+		"\n:100644 100644 1111 2222 M\x00svc/b.go\x00" +
+		"\x1ec2\x001700000100\x00test@example.com\x00committer@example.com\x00Rename a.go to b.go\n\x00" +
+		"\n:100644 100644 1111 2222 R090\x00svc/a.go\x00svc/b.go\x00" +
+		":100644 100644 1111 2222 M\x00other.go\x00" +
+		"\x1ec1\x001700000000\x00test@example.com\x00committer@example.com\x00Add files\n\x00" +
+		"\n:000000 100644 0000 1111 A\x00svc/a.go\x00" +
+		":000000 100644 0000 1111 A\x00svc/c.go\x00" +
+		":000000 100644 0000 1111 A\x00other.go\x00"
+
+	tests := []struct {
+		name             string
+		subdirectory     string
+		transactionLimit int
+		shallow          collection.Set[string]
+		wantTrans        []*Transaction
+		wantFileMap      map[FileID]FilePath
+	}{
+		{
+			name: "Whole repository",
+			wantTrans: []*Transaction{
+				{ID: "c3", Files: makeFileSet(FileID(0))},
+				{ID: "c2", Files: makeFileSet(FileID(0), FileID(1))},
+				{ID: "c1", Files: makeFileSet(FileID(0), FileID(1), FileID(2))},
+			},
+			wantFileMap: map[FileID]FilePath{
+				FileID(0): NewFilePath("svc/b.go"),
+				FileID(1): NewFilePath("other.go"),
+				FileID(2): NewFilePath("svc/c.go"),
+			},
+		},
+		{
+			name:         "Subdirectory",
+			subdirectory: "svc",
+			wantTrans: []*Transaction{
+				{ID: "c3", Files: makeFileSet(FileID(0))},
+				{ID: "c2", Files: makeFileSet(FileID(0))},
+				{ID: "c1", Files: makeFileSet(FileID(0), FileID(1))},
+			},
+			wantFileMap: map[FileID]FilePath{
+				FileID(0): NewFilePath("b.go"),
+				FileID(1): NewFilePath("c.go"),
+			},
+		},
+		{
+			name:    "Shallow clone",
+			shallow: collection.NewSet("c1"),
+			wantTrans: []*Transaction{
+				{ID: "c3", Files: makeFileSet(FileID(0))},
+				{ID: "c2", Files: makeFileSet(FileID(0), FileID(1))},
+			},
+			wantFileMap: map[FileID]FilePath{
+				FileID(0): NewFilePath("svc/b.go"),
+				FileID(1): NewFilePath("other.go"),
+			},
+		},
+		{
+			name:             "Transaction limit",
+			transactionLimit: 1,
+			wantTrans: []*Transaction{
+				{ID: "c3", Files: makeFileSet(FileID(0))},
+			},
+			wantFileMap: map[FileID]FilePath{
+				FileID(0): NewFilePath("svc/b.go"),
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			r := &GitCLIRepository{
+				gitConfig: gitConfig{
+					transactionLimit: tt.transactionLimit,
+				},
+			}
+			WithSubdirectory(tt.subdirectory)(&r.gitConfig)
+
+			gotTrans, gotFileMap, err := r.parseLog(bufio.NewReader(strings.NewReader(log)), tt.shallow)
+			assert.NoError(t, err)
+
+			assert.Equal(t, len(tt.wantTrans), len(gotTrans), "Number of transactions does not match")
+			for i := 0; i < len(tt.wantTrans) && i < len(gotTrans); i++ {
+				assert.Equal(t, tt.wantTrans[i].ID, gotTrans[i].ID)
+				assertSetEqual(t, tt.wantTrans[i].Files, gotTrans[i].Files, "Files of transaction %d", i)
+			}
+			assert.Equal(t, tt.wantFileMap, gotFileMap, "File map does not match")
+		})
+	}
+}
+
+func TestParseGitStatus(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name string
+		out  string
+		want []string
+	}{
+		{
+			name: "Clean",
+			out:  "",
+			want: nil,
+		},
+		{
+			name: "Modified, added and untracked",
+			out:  " M src/b.go\x00A  src/a.go\x00?? new file.go\x00",
+			want: []string{"new file.go", "src/a.go", "src/b.go"},
+		},
+		{
+			name: "Renamed",
+			out:  "R  src/new.go\x00src/old.go\x00 D c.go\x00",
+			want: []string{"c.go", "src/new.go"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			got := parseGitStatus(tt.out)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
@@ -0,0 +1,97 @@
+package tarmaq
+
+import (
+	"math"
+	"slices"
+	"strings"
+	"time"
+)
+
+type Hotspot struct {
+	Path        FilePath
+	Changes     uint64
+	Churn       uint64
+	Score       float64
+	LastChanged time.Time
+}
+
+type HotspotOptions struct {
+	// Now is the reference time of the window and the recency weighting.
+	Now time.Time
+	// Window limits the analysis to transactions newer than Now-Window. Zero means the whole history.
+	Window time.Duration
+	// HalfLife is the age at which the weight of a change is halved. Zero disables recency weighting.
+	HalfLife time.Duration
+	// ChurnWeighted weights each change by the number of changed lines.
+	// Changes without churn information or changed lines, such as of binary files, are weighted as one.
+	ChurnWeighted bool
+	// Limit is the maximum number of hotspots. Zero means no limit.
+	Limit int
+}
+
+// Hotspots returns the most frequently changed files in descending order of score.
+func Hotspots(transactions []*Transaction, fileMap map[FileID]FilePath, options HotspotOptions) []*Hotspot {
+	hotspotMap := make(map[FileID]*Hotspot)
+	for _, tx := range transactions {
+		if options.Window > 0 && tx.Time.Before(options.Now.Add(-options.Window)) {
+			continue
+		}
+
+		weight := 1.0
+		if options.HalfLife > 0 {
+			age := max(options.Now.Sub(tx.Time), 0)
+			weight = math.Pow(0.5, float64(age)/float64(options.HalfLife))
+		}
+
+		for fileID := range tx.Files.Iter() {
+			path, ok := fileMap[fileID]
+			if !ok || path == "" {
+				continue
+			}
+
+			hotspot, ok := hotspotMap[fileID]
+			if !ok {
+				hotspot = &Hotspot{
+					Path: path,
+				}
+				hotspotMap[fileID] = hotspot
+			}
+
+			hotspot.Changes++
+			lines, ok := tx.Churn[fileID]
+			if ok {
+				hotspot.Churn += lines
+			}
+			if options.ChurnWeighted {
+				// every change counts at least once, since binary files have no changed lines
+				hotspot.Score += weight * float64(max(lines, 1))
+			} else {
+				hotspot.Score += weight
+			}
+			if tx.Time.After(hotspot.LastChanged) {
+				hotspot.LastChanged = tx.Time
+			}
+		}
+	}
+
+	hotspots := make([]*Hotspot, 0, len(hotspotMap))
+	for _, hotspot := range hotspotMap {
+		hotspots = append(hotspots, hotspot)
+	}
+	slices.SortFunc(hotspots, func(a, b *Hotspot) int {
+		switch {
+		case a.Score > b.Score:
+			return -1
+		case a.Score < b.Score:
+			return 1
+		default:
+			return strings.Compare(string(a.Path), string(b.Path))
+		}
+	})
+
+	if options.Limit > 0 && len(hotspots) > options.Limit {
+		hotspots = hotspots[:options.Limit]
+	}
+
+	return hotspots
+}
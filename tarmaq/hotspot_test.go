@@ -0,0 +1,109 @@
+package tarmaq
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHotspots(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2025, 1, 31, 0, 0, 0, 0, time.UTC)
+	day := 24 * time.Hour
+	fileMap := map[FileID]FilePath{
+		FileID(0): NewFilePath("a.go"),
+		FileID(1): NewFilePath("b.go"),
+		FileID(2): NewFilePath("c.go"),
+	}
+
+	tests := []struct {
+		name         string
+		transactions []*Transaction
+		options      HotspotOptions
+		want         []*Hotspot
+	}{
+		{
+			name: "Order by change frequency",
+			transactions: []*Transaction{
+				{Time: now.Add(-1 * day), Files: makeFileSet(FileID(0), FileID(1))},
+				{Time: now.Add(-2 * day), Files: makeFileSet(FileID(0))},
+				{Time: now.Add(-3 * day), Files: makeFileSet(FileID(0), FileID(1), FileID(2))},
+			},
+			options: HotspotOptions{Now: now},
+			want: []*Hotspot{
+				{Path: NewFilePath("a.go"), Changes: 3, Score: 3, LastChanged: now.Add(-1 * day)},
+				{Path: NewFilePath("b.go"), Changes: 2, Score: 2, LastChanged: now.Add(-1 * day)},
+				{Path: NewFilePath("c.go"), Changes: 1, Score: 1, LastChanged: now.Add(-3 * day)},
+			},
+		},
+		{
+			name: "Limit and window",
+			transactions: []*Transaction{
+				{Time: now.Add(-1 * day), Files: makeFileSet(FileID(1))},
+				{Time: now.Add(-20 * day), Files: makeFileSet(FileID(0))},
+				{Time: now.Add(-21 * day), Files: makeFileSet(FileID(0))},
+			},
+			options: HotspotOptions{Now: now, Window: 10 * day, Limit: 1},
+			want: []*Hotspot{
+				{Path: NewFilePath("b.go"), Changes: 1, Score: 1, LastChanged: now.Add(-1 * day)},
+			},
+		},
+		{
+			name: "Recency weighting",
+			transactions: []*Transaction{
+				{Time: now, Files: makeFileSet(FileID(1))},
+				{Time: now.Add(-10 * day), Files: makeFileSet(FileID(0))},
+				{Time: now.Add(-20 * day), Files: makeFileSet(FileID(0))},
+			},
+			options: HotspotOptions{Now: now, HalfLife: 10 * day},
+			want: []*Hotspot{
+				{Path: NewFilePath("b.go"), Changes: 1, Score: 1, LastChanged: now},
+				{Path: NewFilePath("a.go"), Changes: 2, Score: 0.75, LastChanged: now.Add(-10 * day)},
+			},
+		},
+		{
+			name: "Churn weighting",
+			transactions: []*Transaction{
+				{Time: now, Files: makeFileSet(FileID(0), FileID(1)), Churn: map[FileID]uint64{0: 2, 1: 50}},
+				{Time: now, Files: makeFileSet(FileID(0)), Churn: map[FileID]uint64{0: 3}},
+			},
+			options: HotspotOptions{Now: now, ChurnWeighted: true},
+			want: []*Hotspot{
+				{Path: NewFilePath("b.go"), Changes: 1, Churn: 50, Score: 50, LastChanged: now},
+				{Path: NewFilePath("a.go"), Changes: 2, Churn: 5, Score: 5, LastChanged: now},
+			},
+		},
+		{
+			name: "Churn weighting without changed lines",
+			transactions: []*Transaction{
+				{Time: now, Files: makeFileSet(FileID(0), FileID(1)), Churn: map[FileID]uint64{0: 0, 1: 1}},
+				{Time: now, Files: makeFileSet(FileID(0)), Churn: map[FileID]uint64{0: 0}},
+				{Time: now, Files: makeFileSet(FileID(0))},
+			},
+			options: HotspotOptions{Now: now, ChurnWeighted: true},
+			want: []*Hotspot{
+				{Path: NewFilePath("a.go"), Changes: 3, Churn: 0, Score: 3, LastChanged: now},
+				{Path: NewFilePath("b.go"), Changes: 1, Churn: 1, Score: 1, LastChanged: now},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			got := Hotspots(tt.transactions, fileMap, tt.options)
+
+			assert.Len(t, got, len(tt.want))
+			for i := range min(len(got), len(tt.want)) {
+				assert.Equal(t, tt.want[i].Path, got[i].Path, "Path of hotspot %d", i)
+				assert.Equal(t, tt.want[i].Changes, got[i].Changes, "Changes of hotspot %d", i)
+				assert.Equal(t, tt.want[i].Churn, got[i].Churn, "Churn of hotspot %d", i)
+				assert.InDelta(t, tt.want[i].Score, got[i].Score, 1e-9, "Score of hotspot %d", i)
+				assert.Equal(t, tt.want[i].LastChanged, got[i].LastChanged, "LastChanged of hotspot %d", i)
+			}
+		})
+	}
+}
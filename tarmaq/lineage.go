@@ -0,0 +1,47 @@
+package tarmaq
+
+import (
+	"slices"
+	"time"
+)
+
+type FileName struct {
+	Path FilePath
+	// RenamedIn is the ID of the transaction that renamed the file to Path.
+	// It is empty for the oldest name.
+	RenamedIn string
+	RenamedAt time.Time
+}
+
+// Lineage returns the names of the file oldest first.
+// Transactions must be ordered newest first, as returned by Repository.GetTransactions.
+// It returns false if the file is not found in the file map.
+func Lineage(transactions []*Transaction, fileMap map[FileID]FilePath, path FilePath) ([]*FileName, bool) {
+	fileID, ok := findFileID(fileMap, path)
+	if !ok {
+		return nil, false
+	}
+
+	names := []*FileName{}
+	current := path
+	for _, tx := range transactions {
+		for _, rename := range tx.Renames {
+			if rename.File != fileID {
+				continue
+			}
+
+			names = append(names, &FileName{
+				Path:      rename.To,
+				RenamedIn: tx.ID,
+				RenamedAt: tx.Time,
+			})
+			current = rename.From
+		}
+	}
+	names = append(names, &FileName{
+		Path: current,
+	})
+	slices.Reverse(names)
+
+	return names, true
+}
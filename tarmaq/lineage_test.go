@@ -0,0 +1,79 @@
+package tarmaq
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLineage(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2025, 1, 31, 0, 0, 0, 0, time.UTC)
+	fileMap := map[FileID]FilePath{
+		FileID(0): NewFilePath("pkg/server.go"),
+		FileID(1): NewFilePath("main.go"),
+	}
+	transactions := []*Transaction{
+		{
+			ID:      "c3",
+			Time:    now,
+			Files:   makeFileSet(FileID(0)),
+			Renames: []*Rename{{File: FileID(0), From: NewFilePath("server/server.go"), To: NewFilePath("pkg/server.go")}},
+		},
+		{
+			ID:    "c2",
+			Time:  now.Add(-time.Hour),
+			Files: makeFileSet(FileID(0), FileID(1)),
+		},
+		{
+			ID:      "c1",
+			Time:    now.Add(-2 * time.Hour),
+			Files:   makeFileSet(FileID(0)),
+			Renames: []*Rename{{File: FileID(0), From: NewFilePath("server.go"), To: NewFilePath("server/server.go")}},
+		},
+	}
+
+	tests := []struct {
+		name   string
+		path   FilePath
+		want   []*FileName
+		wantOK bool
+	}{
+		{
+			name: "Renamed twice",
+			path: NewFilePath("pkg/server.go"),
+			want: []*FileName{
+				{Path: NewFilePath("server.go")},
+				{Path: NewFilePath("server/server.go"), RenamedIn: "c1", RenamedAt: now.Add(-2 * time.Hour)},
+				{Path: NewFilePath("pkg/server.go"), RenamedIn: "c3", RenamedAt: now},
+			},
+			wantOK: true,
+		},
+		{
+			name: "Never renamed",
+			path: NewFilePath("main.go"),
+			want: []*FileName{
+				{Path: NewFilePath("main.go")},
+			},
+			wantOK: true,
+		},
+		{
+			name:   "Unknown file",
+			path:   NewFilePath("unknown.go"),
+			wantOK: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			got, ok := Lineage(transactions, fileMap, tt.path)
+
+			assert.Equal(t, tt.wantOK, ok)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
@@ -0,0 +1,104 @@
+package tarmaq
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+)
+
+// AuthorReporter is implemented by repositories which know their user and the aliases of authors.
+type AuthorReporter interface {
+	// Identity returns the email address of the configured user, or an empty string without one.
+	Identity() (string, error)
+	// Mailmap returns the aliases in the .mailmap file of HEAD, which is empty without the file.
+	Mailmap() (*Mailmap, error)
+}
+
+// Mailmap maps the email addresses of authors to their canonical ones, as .mailmap files of git.
+// Names are ignored, since transactions only have email addresses.
+type Mailmap struct {
+	emails map[string]string
+}
+
+func NewMailmap() *Mailmap {
+	return &Mailmap{
+		emails: make(map[string]string),
+	}
+}
+
+// mailmapEmailPattern matches the email addresses of a .mailmap entry.
+var mailmapEmailPattern = regexp.MustCompile(`<([^>]*)>`)
+
+// ParseMailmap parses a .mailmap file.
+// Entries with a commit email address, e.g. "Name <proper@example.com> <commit@example.com>", map it to the proper one.
+func ParseMailmap(r io.Reader) (*Mailmap, error) {
+	mailmap := NewMailmap()
+
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		matches := mailmapEmailPattern.FindAllStringSubmatch(stripMailmapComment(scanner.Text()), -1)
+		if len(matches) < 2 {
+			// entries with a single email address only fix names
+			continue
+		}
+		mailmap.Add(matches[1][1], matches[0][1])
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("read mailmap: %w", err)
+	}
+
+	return mailmap, nil
+}
+
+// stripMailmapComment removes the comment starting with # outside of email addresses.
+func stripMailmapComment(line string) string {
+	inEmail := false
+	for i, c := range line {
+		switch {
+		case c == '<':
+			inEmail = true
+		case c == '>':
+			inEmail = false
+		case c == '#' && !inEmail:
+			return line[:i]
+		}
+	}
+
+	return line
+}
+
+// MergeMailmaps merges the mailmaps. Later mailmaps take precedence.
+func MergeMailmaps(mailmaps ...*Mailmap) *Mailmap {
+	merged := NewMailmap()
+	for _, mailmap := range mailmaps {
+		if mailmap == nil {
+			continue
+		}
+		for alias, email := range mailmap.emails {
+			merged.emails[alias] = email
+		}
+	}
+
+	return merged
+}
+
+// Add maps the alias to the email address.
+func (m *Mailmap) Add(alias string, email string) {
+	m.emails[strings.ToLower(alias)] = strings.ToLower(email)
+}
+
+// Email returns the canonical email address in lower case, since email addresses are compared ignoring case.
+// A nil mailmap only changes the case.
+func (m *Mailmap) Email(email string) string {
+	email = strings.ToLower(email)
+	if m == nil {
+		return email
+	}
+	if canonical, ok := m.emails[email]; ok {
+		return canonical
+	}
+
+	return email
+}
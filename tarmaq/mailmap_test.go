@@ -0,0 +1,58 @@
+package tarmaq
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseMailmap(t *testing.T) {
+	t.Parallel()
+
+	mailmap, err := ParseMailmap(strings.NewReader(`# aliases of the team
+Alice <alice@example.com>
+Alice <alice@example.com> <alice@old.example.com>
+<bob@example.com> <Bob@Laptop.local> # laptop
+Bob <bob@example.com> bobby <bobby@example.com>
+Carol <carol@example.com> <carol#1@example.com>
+`))
+	assert.NoError(t, err)
+
+	tests := []struct {
+		email string
+		want  string
+	}{
+		{email: "alice@example.com", want: "alice@example.com"},
+		{email: "alice@old.example.com", want: "alice@example.com"},
+		{email: "bob@laptop.local", want: "bob@example.com"},
+		{email: "BOBBY@example.com", want: "bob@example.com"},
+		{email: "carol#1@example.com", want: "carol@example.com"},
+		{email: "Dave@example.com", want: "dave@example.com"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.email, func(t *testing.T) {
+			t.Parallel()
+
+			assert.Equal(t, tt.want, mailmap.Email(tt.email))
+		})
+	}
+}
+
+func TestMergeMailmaps(t *testing.T) {
+	t.Parallel()
+
+	a := NewMailmap()
+	a.Add("alice@old.example.com", "alice@example.com")
+	a.Add("bob@old.example.com", "bob@example.com")
+	b := NewMailmap()
+	b.Add("bob@old.example.com", "bob@new.example.com")
+
+	merged := MergeMailmaps(a, nil, b)
+	assert.Equal(t, "alice@example.com", merged.Email("alice@old.example.com"))
+	assert.Equal(t, "bob@new.example.com", merged.Email("bob@old.example.com"))
+
+	var empty *Mailmap
+	assert.Equal(t, "alice@example.com", empty.Email("Alice@example.com"))
+}
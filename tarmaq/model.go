@@ -2,6 +2,7 @@ package tarmaq
 
 import (
 	"path/filepath"
+	"time"
 
 	"github.com/mazrean/mcp-tarmaq/pkg/collection"
 )
@@ -18,10 +19,21 @@ func (f *FileIDGenerator) Next() FileID {
 	return id
 }
 
+// FilePath is a slash separated path relative to the root of a repository, regardless of the OS.
 type FilePath string
 
 func NewFilePath(path string) FilePath {
-	return FilePath(filepath.FromSlash(path))
+	return FilePath(filepath.ToSlash(path))
+}
+
+func findFileID(fileMap map[FileID]FilePath, path FilePath) (FileID, bool) {
+	for id, p := range fileMap {
+		if p == path {
+			return id, true
+		}
+	}
+
+	return 0, false
 }
 
 type Query struct {
@@ -33,7 +45,49 @@ func (q *Query) Apply(transaction *Transaction) (intersection collection.Set[Fil
 }
 
 type Transaction struct {
-	Files collection.Set[FileID]
+	ID   string
+	Time time.Time
+	// Author is the email address of the author.
+	Author string
+	// Committer is the email address of the committer.
+	// It is empty when the repository does not distinguish committers from authors.
+	Committer string
+	Message   string
+	Files     collection.Set[FileID]
+	// Churn is the number of added and deleted lines per file.
+	// It is nil when the repository does not collect churn.
+	Churn map[FileID]uint64
+	// Renames are the files renamed in the transaction.
+	Renames []*Rename
+	// Deleted are the files deleted in the transaction.
+	Deleted collection.Set[FileID]
+}
+
+// RemovedFiles returns the files whose latest change is a deletion, i.e. files that no longer exist.
+// Transactions must be ordered newest first, as returned by Repository.GetTransactions.
+func RemovedFiles(transactions []*Transaction) collection.Set[FileID] {
+	seen := collection.NewSet[FileID]()
+	removed := collection.NewSet[FileID]()
+	for _, tx := range transactions {
+		for fileID := range tx.Files.Iter() {
+			if seen.Contains(fileID) {
+				continue
+			}
+			seen.Add(fileID)
+
+			if tx.Deleted.Contains(fileID) {
+				removed.Add(fileID)
+			}
+		}
+	}
+
+	return removed
+}
+
+type Rename struct {
+	File FileID
+	From FilePath
+	To   FilePath
 }
 
 type Rule struct {
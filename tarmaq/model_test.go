@@ -23,3 +23,43 @@ func assertSetEqual(t *testing.T, expected, actual collection.Set[FileID], msgAn
 	actualSlice := slices.Collect(actual.Iter())
 	assert.ElementsMatch(t, expectedSlice, actualSlice, msgAndArgs...)
 }
+
+func TestRemovedFiles(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name         string
+		transactions []*Transaction
+		want         collection.Set[FileID]
+	}{
+		{
+			name: "Deleted in the latest change",
+			transactions: []*Transaction{
+				{Files: makeFileSet(FileID(0), FileID(1)), Deleted: makeFileSet(FileID(0))},
+				{Files: makeFileSet(FileID(0), FileID(1))},
+			},
+			want: makeFileSet(FileID(0)),
+		},
+		{
+			name: "Deleted and added again",
+			transactions: []*Transaction{
+				{Files: makeFileSet(FileID(0))},
+				{Files: makeFileSet(FileID(0)), Deleted: makeFileSet(FileID(0))},
+			},
+			want: makeFileSet(),
+		},
+		{
+			name:         "No transactions",
+			transactions: []*Transaction{},
+			want:         makeFileSet(),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			assertSetEqual(t, tt.want, RemovedFiles(tt.transactions))
+		})
+	}
+}
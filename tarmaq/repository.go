@@ -1,12 +1,22 @@
 package tarmaq
 
 import (
+	"context"
 	"errors"
 	"fmt"
+	"io"
 	"log/slog"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
 
 	git "github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/config"
+	"github.com/go-git/go-git/v5/plumbing"
 	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/go-git/go-git/v5/plumbing/storer"
 	"github.com/mazrean/mcp-tarmaq/pkg/collection"
 )
 
@@ -14,39 +24,372 @@ type Repository interface {
 	GetTransactions() ([]*Transaction, map[FileID]FilePath, error)
 }
 
-var _ Repository = &GitRepository{}
+// RefSelector is implemented by repositories whose history can be mined from selected refs.
+type RefSelector interface {
+	// SelectRefs returns a repository that mines the history reachable from refs,
+	// or from all branches if all is true.
+	SelectRefs(refs []string, all bool) Repository
+}
+
+var (
+	_ Repository       = &GitRepository{}
+	_ RefSelector      = &GitRepository{}
+	_ HeadResolver     = &GitRepository{}
+	_ ShallowReporter  = &GitRepository{}
+	_ WorktreeReporter = &GitRepository{}
+	_ AuthorReporter   = &GitRepository{}
+)
 
 type GitRepository struct {
-	repo             *git.Repository
+	gitConfig
+	repo *git.Repository
+	// dir is the path the repository is opened from.
+	dir string
+	// root is the absolute path of the working tree. It is empty for bare repositories.
+	root string
+}
+
+// gitConfig is the configuration shared by the git backends.
+type gitConfig struct {
 	transactionLimit int
+	collectChurn     bool
+	renameScore      uint
+	refs             []string
+	allRefs          bool
+	// subdirectory limits mining to files under it, with paths relative to it.
+	subdirectory string
 }
 
-func NewGitRepository(repoPath string, transactionLimit int) (*GitRepository, error) {
-	repo, err := git.PlainOpen(repoPath)
+// DefaultRenameScore is the default similarity threshold in percent for rename detection.
+const DefaultRenameScore = 60
+
+// GitRepositoryOption configures GitRepository and GitCLIRepository.
+type GitRepositoryOption func(*gitConfig)
+
+// WithChurn makes the repository collect the number of changed lines per file.
+// This requires computing a patch for every commit, so it is disabled by default.
+func WithChurn() GitRepositoryOption {
+	return func(r *gitConfig) {
+		r.collectChurn = true
+	}
+}
+
+// WithRenameScore sets the similarity threshold in percent to consider a deleted and an added file as a rename.
+// 100 detects exact renames only and 0 disables rename detection.
+func WithRenameScore(score uint) GitRepositoryOption {
+	return func(r *gitConfig) {
+		r.renameScore = score
+	}
+}
+
+// WithRefs mines the history reachable from the refs instead of HEAD.
+// Commits reachable from several refs are counted once.
+func WithRefs(refs ...string) GitRepositoryOption {
+	return func(r *gitConfig) {
+		r.refs = refs
+	}
+}
+
+// WithAllRefs mines the history reachable from all local and remote branches.
+func WithAllRefs() GitRepositoryOption {
+	return func(r *gitConfig) {
+		r.allRefs = true
+	}
+}
+
+// WithSubdirectory limits mining to files under the directory, with paths relative to it.
+func WithSubdirectory(dir string) GitRepositoryOption {
+	return func(r *gitConfig) {
+		r.subdirectory = cleanSubdirectory(dir)
+	}
+}
+
+func cleanSubdirectory(dir string) string {
+	dir = strings.Trim(path.Clean(filepath.ToSlash(dir)), "/")
+	if dir == "." {
+		return ""
+	}
+
+	return dir
+}
+
+// NewGitRepository opens the repository containing repoPath.
+// If repoPath is a subdirectory of the working tree, mining is limited to it.
+func NewGitRepository(repoPath string, transactionLimit int, options ...GitRepositoryOption) (*GitRepository, error) {
+	repo, err := git.PlainOpenWithOptions(repoPath, &git.PlainOpenOptions{
+		DetectDotGit: true,
+	})
 	if err != nil {
 		return nil, err
 	}
 
-	return &GitRepository{
-		repo:             repo,
-		transactionLimit: transactionLimit,
-	}, nil
+	r := &GitRepository{
+		gitConfig: gitConfig{
+			transactionLimit: transactionLimit,
+			renameScore:      DefaultRenameScore,
+		},
+		repo: repo,
+		dir:  repoPath,
+	}
+
+	wt, err := repo.Worktree()
+	switch {
+	case errors.Is(err, git.ErrIsBareRepository):
+	case err != nil:
+		return nil, fmt.Errorf("get worktree: %w", err)
+	default:
+		r.root = wt.Filesystem.Root()
+
+		dir, err := relativeDir(r.root, repoPath)
+		if err != nil {
+			return nil, err
+		}
+		WithSubdirectory(dir)(&r.gitConfig)
+	}
+
+	for _, option := range options {
+		option(&r.gitConfig)
+	}
+
+	return r, nil
+}
+
+func relativeDir(root string, dir string) (string, error) {
+	absRoot, err := filepath.Abs(root)
+	if err != nil {
+		return "", fmt.Errorf("get absolute path of %s: %w", root, err)
+	}
+	if evalRoot, err := filepath.EvalSymlinks(absRoot); err == nil {
+		absRoot = evalRoot
+	}
+
+	absDir, err := filepath.Abs(dir)
+	if err != nil {
+		return "", fmt.Errorf("get absolute path of %s: %w", dir, err)
+	}
+	if evalDir, err := filepath.EvalSymlinks(absDir); err == nil {
+		absDir = evalDir
+	}
+
+	rel, err := filepath.Rel(absRoot, absDir)
+	if err != nil {
+		return "", fmt.Errorf("get relative path of %s: %w", dir, err)
+	}
+
+	return rel, nil
+}
+
+// scope returns the path relative to the subdirectory, or false if the path is outside of it.
+func (r *gitConfig) scope(name string) (string, bool) {
+	return scopePath(r.subdirectory, name)
+}
+
+// scopePath returns the path relative to dir, or false if the path is outside of it.
+func scopePath(dir string, name string) (string, bool) {
+	if name == "" {
+		return "", false
+	}
+	if dir == "" {
+		return name, true
+	}
+
+	rel, ok := strings.CutPrefix(name, dir+"/")
+	return rel, ok
+}
+
+// Submodules returns the absolute paths of the checked out submodules under the subdirectory,
+// keyed by their paths relative to the subdirectory.
+func (r *GitRepository) Submodules() (map[string]string, error) {
+	if r.root == "" {
+		return map[string]string{}, nil
+	}
+
+	wt, err := r.repo.Worktree()
+	if err != nil {
+		return nil, fmt.Errorf("get worktree: %w", err)
+	}
+
+	submodules, err := wt.Submodules()
+	if err != nil {
+		return nil, fmt.Errorf("get submodules: %w", err)
+	}
+
+	paths := make(map[string]string, len(submodules))
+	for _, submodule := range submodules {
+		subPath := path.Clean(filepath.ToSlash(submodule.Config().Path))
+		rel, ok := r.scope(subPath)
+		if !ok {
+			continue
+		}
+
+		absPath := filepath.Join(r.root, filepath.FromSlash(subPath))
+		if _, err := os.Stat(filepath.Join(absPath, ".git")); err != nil {
+			slog.Warn("submodule is not checked out",
+				slog.String("submodule", subPath),
+			)
+			continue
+		}
+		paths[rel] = absPath
+	}
+
+	return paths, nil
+}
+
+func (r *GitRepository) SelectRefs(refs []string, all bool) Repository {
+	selected := *r
+	selected.refs = refs
+	selected.allRefs = all
+
+	return &selected
+}
+
+// Head returns the hash of HEAD, or the hashes of the selected refs separated by commas.
+func (r *GitRepository) Head() (string, error) {
+	if len(r.refs) == 0 && !r.allRefs {
+		ref, err := r.repo.Head()
+		if err != nil {
+			return "", fmt.Errorf("get HEAD: %w", err)
+		}
+
+		return ref.Hash().String(), nil
+	}
+
+	hashes, err := r.refHashes()
+	if err != nil {
+		return "", err
+	}
+
+	heads := make([]string, 0, len(hashes))
+	for _, hash := range hashes {
+		heads = append(heads, hash.String())
+	}
+
+	return strings.Join(heads, ","), nil
+}
+
+func (r *GitRepository) ChangedFiles() ([]FilePath, error) {
+	wt, err := r.repo.Worktree()
+	if errors.Is(err, git.ErrIsBareRepository) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("get worktree: %w", err)
+	}
+
+	status, err := wt.Status()
+	if err != nil {
+		return nil, fmt.Errorf("get status: %w", err)
+	}
+
+	var files []FilePath
+	for name, fileStatus := range status {
+		if fileStatus.Staging == git.Unmodified && fileStatus.Worktree == git.Unmodified {
+			continue
+		}
+		if rel, ok := r.scope(name); ok {
+			files = append(files, NewFilePath(rel))
+		}
+	}
+	slices.Sort(files)
+
+	return files, nil
+}
+
+func (r *GitRepository) Identity() (string, error) {
+	cfg, err := r.repo.ConfigScoped(config.GlobalScope)
+	if err != nil {
+		return "", fmt.Errorf("get config: %w", err)
+	}
+
+	return cfg.User.Email, nil
+}
+
+func (r *GitRepository) Mailmap() (*Mailmap, error) {
+	ref, err := r.repo.Head()
+	if errors.Is(err, plumbing.ErrReferenceNotFound) {
+		return NewMailmap(), nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("get HEAD: %w", err)
+	}
+
+	commit, err := r.repo.CommitObject(ref.Hash())
+	if err != nil {
+		return nil, fmt.Errorf("get HEAD commit: %w", err)
+	}
+
+	file, err := commit.File(".mailmap")
+	if errors.Is(err, object.ErrFileNotFound) {
+		return NewMailmap(), nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("get .mailmap: %w", err)
+	}
+
+	reader, err := file.Reader()
+	if err != nil {
+		return nil, fmt.Errorf("open .mailmap: %w", err)
+	}
+	defer reader.Close()
+
+	return ParseMailmap(reader)
+}
+
+func (r *GitRepository) Depth() (int, bool, error) {
+	shallow, err := r.repo.Storer.Shallow()
+	if err != nil {
+		return 0, false, fmt.Errorf("get shallow commits: %w", err)
+	}
+	if len(shallow) == 0 {
+		return 0, false, nil
+	}
+
+	commitIter, err := r.log()
+	if err != nil {
+		return 0, false, fmt.Errorf("get commit iterator: %w", err)
+	}
+	defer commitIter.Close()
+
+	// the boundary commits are not counted, since GetTransactions skips them
+	boundary := collection.NewSet(shallow...)
+	depth := 0
+	for commit, err := commitIter.Next(); err == nil; commit, err = commitIter.Next() {
+		if !boundary.Contains(commit.Hash) {
+			depth++
+		}
+	}
+
+	return depth, true, nil
+}
+
+func (r *GitRepository) Deepen(commits int) error {
+	return deepen(r.dir, commits)
 }
 
 func (r *GitRepository) GetTransactions() ([]*Transaction, map[FileID]FilePath, error) {
-	commitIter, err := r.repo.Log(&git.LogOptions{})
+	commitIter, err := r.log()
 	if err != nil {
 		return nil, nil, fmt.Errorf("get commit iterator: %w", err)
 	}
 	defer commitIter.Close()
 
-	idGenerator := FileIDGenerator{0}
+	shallowHashes, err := r.repo.Storer.Shallow()
+	if err != nil {
+		return nil, nil, fmt.Errorf("get shallow commits: %w", err)
+	}
+	shallow := collection.NewSet(shallowHashes...)
+
+	history := newHistoryBuilder(r.scope)
 	var transactions []*Transaction
-	latestFileMap := make(map[FileID]FilePath)
-	fileIDMap := make(map[string]FileID)
 
 	for commit, err := commitIter.Next(); err == nil; commit, err = commitIter.Next() {
-		files := collection.NewSet[FileID]()
+		if shallow.Contains(commit.Hash) {
+			// the parents of the boundary commits of a shallow clone are missing
+			slog.Debug("skip shallow commit",
+				slog.String("commit", commit.Hash.String()),
+			)
+			continue
+		}
 
 		var parentTree *object.Tree
 		// get first parent(main branch in most cases)
@@ -82,7 +425,11 @@ func (r *GitRepository) GetTransactions() ([]*Transaction, map[FileID]FilePath,
 			continue
 		}
 
-		changes, err := parentTree.Diff(commitTree)
+		changes, err := object.DiffTreeWithOptions(context.Background(), parentTree, commitTree, &object.DiffTreeOptions{
+			DetectRenames:    r.renameScore > 0,
+			RenameScore:      r.renameScore,
+			OnlyExactRenames: r.renameScore >= 100,
+		})
 		if err != nil {
 			slog.Warn("failed to get diff",
 				slog.String("commit", commit.Hash.String()),
@@ -91,32 +438,248 @@ func (r *GitRepository) GetTransactions() ([]*Transaction, map[FileID]FilePath,
 			continue
 		}
 
+		tx := &Transaction{
+			ID:        commit.Hash.String(),
+			Time:      commit.Committer.When,
+			Author:    commit.Author.Email,
+			Committer: commit.Committer.Email,
+			Message:   commit.Message,
+			Files:     collection.NewSet[FileID](),
+		}
+		if r.collectChurn {
+			tx.Churn = make(map[FileID]uint64)
+		}
 		for _, change := range changes {
-			// add file to transaction if it's added or modified
-			fileID, ok := fileIDMap[change.To.Name]
+			fileID, ok := history.add(tx, change.From.Name, change.To.Name)
 			if !ok {
-				fileID = idGenerator.Next()
-				latestFileMap[fileID] = NewFilePath(change.To.Name)
-				fileIDMap[change.To.Name] = fileID
+				continue
 			}
-			files.Add(fileID)
 
-			// edit fileIDMap if file is renamed
-			if change.From.Name != change.To.Name {
-				delete(fileIDMap, change.To.Name)
-				fileIDMap[change.From.Name] = fileID
+			if tx.Churn != nil {
+				lines, err := changeChurn(change)
+				if err != nil {
+					slog.Warn("failed to get churn",
+						slog.String("commit", commit.Hash.String()),
+						slog.String("file", string(history.fileMap[fileID])),
+						slog.String("error", err.Error()),
+					)
+				} else {
+					tx.Churn[fileID] += lines
+				}
 			}
 		}
 
-		if files.Len() > 0 {
-			transactions = append(transactions, &Transaction{
-				Files: files,
-			})
+		if tx.Files.Len() > 0 {
+			transactions = append(transactions, tx)
 			if r.transactionLimit != 0 && len(transactions) >= r.transactionLimit {
 				break
 			}
 		}
 	}
 
-	return transactions, latestFileMap, nil
+	return transactions, history.fileMap, nil
+}
+
+func (r *GitRepository) log() (object.CommitIter, error) {
+	if len(r.refs) == 0 && !r.allRefs {
+		return r.repo.Log(&git.LogOptions{})
+	}
+
+	hashes, err := r.refHashes()
+	if err != nil {
+		return nil, err
+	}
+
+	if len(hashes) == 1 {
+		return r.repo.Log(&git.LogOptions{
+			From: hashes[0],
+		})
+	}
+
+	// merge the histories of refs in committer time order, skipping shared commits
+	seen := make(map[plumbing.Hash]struct{})
+	var commits []*object.Commit
+	for _, hash := range hashes {
+		iter, err := r.repo.Log(&git.LogOptions{
+			From:  hash,
+			Order: git.LogOrderCommitterTime,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("get commit iterator of %s: %w", hash, err)
+		}
+
+		err = iter.ForEach(func(commit *object.Commit) error {
+			if _, ok := seen[commit.Hash]; ok {
+				return nil
+			}
+			seen[commit.Hash] = struct{}{}
+			commits = append(commits, commit)
+
+			return nil
+		})
+		iter.Close()
+		if err != nil {
+			return nil, fmt.Errorf("iterate commits of %s: %w", hash, err)
+		}
+	}
+	slices.SortStableFunc(commits, func(a, b *object.Commit) int {
+		return b.Committer.When.Compare(a.Committer.When)
+	})
+
+	return &commitSliceIter{commits: commits}, nil
+}
+
+func (r *GitRepository) refHashes() ([]plumbing.Hash, error) {
+	seen := make(map[plumbing.Hash]struct{})
+	var hashes []plumbing.Hash
+	add := func(hash plumbing.Hash) {
+		if _, ok := seen[hash]; ok {
+			return
+		}
+		seen[hash] = struct{}{}
+		hashes = append(hashes, hash)
+	}
+
+	for _, ref := range r.refs {
+		hash, err := r.repo.ResolveRevision(plumbing.Revision(ref))
+		if err != nil {
+			return nil, fmt.Errorf("resolve ref %s: %w", ref, err)
+		}
+		add(*hash)
+	}
+
+	if r.allRefs {
+		refIter, err := r.repo.References()
+		if err != nil {
+			return nil, fmt.Errorf("get references: %w", err)
+		}
+		err = refIter.ForEach(func(ref *plumbing.Reference) error {
+			if ref.Type() == plumbing.HashReference && (ref.Name().IsBranch() || ref.Name().IsRemote()) {
+				add(ref.Hash())
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, fmt.Errorf("iterate references: %w", err)
+		}
+	}
+
+	if len(hashes) == 0 {
+		return nil, errors.New("no refs to mine")
+	}
+
+	return hashes, nil
+}
+
+type commitSliceIter struct {
+	commits []*object.Commit
+	pos     int
+}
+
+func (i *commitSliceIter) Next() (*object.Commit, error) {
+	if i.pos >= len(i.commits) {
+		return nil, io.EOF
+	}
+	commit := i.commits[i.pos]
+	i.pos++
+
+	return commit, nil
+}
+
+func (i *commitSliceIter) ForEach(cb func(*object.Commit) error) error {
+	for commit, err := i.Next(); err == nil; commit, err = i.Next() {
+		if err := cb(commit); err != nil {
+			if errors.Is(err, storer.ErrStop) {
+				return nil
+			}
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (i *commitSliceIter) Close() {
+	i.pos = len(i.commits)
+}
+
+// fileChange is a change from the path from to the path to, which are empty for additions and deletions respectively.
+type fileChange struct {
+	from string
+	to   string
+}
+
+// historyBuilder identifies the files changed by commits visited from the newest to the oldest.
+// Files are identified across renames and named by their latest paths.
+type historyBuilder struct {
+	scope       func(string) (string, bool)
+	idGenerator FileIDGenerator
+	fileMap     map[FileID]FilePath
+	fileIDMap   map[string]FileID
+}
+
+func newHistoryBuilder(scope func(string) (string, bool)) *historyBuilder {
+	return &historyBuilder{
+		scope:     scope,
+		fileMap:   make(map[FileID]FilePath),
+		fileIDMap: make(map[string]FileID),
+	}
+}
+
+// add records a change of tx from the path from to the path to, which are empty for additions and deletions respectively.
+// It returns false if both paths are out of the scope.
+func (b *historyBuilder) add(tx *Transaction, from string, to string) (FileID, bool) {
+	fromName, fromOK := b.scope(from)
+	toName, toOK := b.scope(to)
+	if !fromOK && !toOK {
+		return 0, false
+	}
+
+	name := toName
+	if !toOK {
+		name = fromName
+	}
+
+	fileID, ok := b.fileIDMap[name]
+	if !ok {
+		fileID = b.idGenerator.Next()
+		b.fileMap[fileID] = NewFilePath(name)
+		b.fileIDMap[name] = fileID
+	}
+	tx.Files.Add(fileID)
+
+	if !toOK {
+		if tx.Deleted == nil {
+			tx.Deleted = collection.NewSet[FileID]()
+		}
+		tx.Deleted.Add(fileID)
+	}
+
+	// older commits refer to a renamed file by its previous name
+	if fromOK && toOK && fromName != toName {
+		delete(b.fileIDMap, toName)
+		b.fileIDMap[fromName] = fileID
+		tx.Renames = append(tx.Renames, &Rename{
+			File: fileID,
+			From: NewFilePath(fromName),
+			To:   NewFilePath(toName),
+		})
+	}
+
+	return fileID, true
+}
+
+func changeChurn(change *object.Change) (uint64, error) {
+	patch, err := change.Patch()
+	if err != nil {
+		return 0, fmt.Errorf("get patch: %w", err)
+	}
+
+	var lines uint64
+	for _, stat := range patch.Stats() {
+		//nolint:gosec
+		lines += uint64(stat.Addition + stat.Deletion)
+	}
+
+	return lines, nil
 }
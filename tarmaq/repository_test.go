@@ -7,6 +7,7 @@ import (
 
 	"github.com/go-git/go-billy/v5/memfs"
 	git "github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
 	"github.com/go-git/go-git/v5/plumbing/object"
 	"github.com/go-git/go-git/v5/storage/memory"
 	"github.com/stretchr/testify/assert"
@@ -26,6 +27,13 @@ func createMockRepo(commits []mockCommit) (*git.Repository, error) {
 	}
 
 	for _, commit := range commits {
+		for _, path := range commit.removed {
+			_, err := wt.Remove(path)
+			if err != nil {
+				return nil, err
+			}
+		}
+
 		for path, content := range commit.files {
 			err := func() error {
 				f, err := fs.Create(path)
@@ -69,17 +77,20 @@ func createMockRepo(commits []mockCommit) (*git.Repository, error) {
 type mockCommit struct {
 	message string
 	files   map[string]string // Map of file paths and contents
+	removed []string          // File paths to remove
 }
 
 func TestGitRepository_GetTransactions(t *testing.T) {
 	t.Parallel()
 
 	tests := []struct {
-		name        string
-		commits     []mockCommit
-		wantTrans   []*Transaction
-		wantFileMap map[FileID]FilePath
-		wantErr     bool
+		name         string
+		commits      []mockCommit
+		wantTrans    []*Transaction
+		renameScore  uint
+		subdirectory string
+		wantFileMap  map[FileID]FilePath
+		wantErr      bool
 	}{
 		{
 			name: "Add one file in one commit",
@@ -193,6 +204,152 @@ func TestGitRepository_GetTransactions(t *testing.T) {
 			},
 			wantErr: false,
 		},
+		{
+			name: "Delete file",
+			commits: []mockCommit{
+				{
+					message: "Add initial files",
+					files: map[string]string{
+						"file1.txt": "content1",
+						"file2.txt": "content2",
+					},
+				},
+				{
+					message: "Delete file1.txt",
+					files: map[string]string{
+						"file2.txt": "updated content2",
+					},
+					removed: []string{"file1.txt"},
+				},
+			},
+			wantTrans: []*Transaction{
+				{
+					Files:   makeFileSet(FileID(0), FileID(1)),
+					Deleted: makeFileSet(FileID(0)),
+				},
+				{
+					Files: makeFileSet(FileID(0), FileID(1)),
+				},
+			},
+			wantFileMap: map[FileID]FilePath{
+				FileID(0): NewFilePath("file1.txt"),
+				FileID(1): NewFilePath("file2.txt"),
+			},
+			wantErr: false,
+		},
+		{
+			name: "Rename file",
+			commits: []mockCommit{
+				{
+					message: "Add file1.txt",
+					files: map[string]string{
+						"file1.txt": "line1\nline2\nline3\nline4\nline5\n",
+					},
+				},
+				{
+					message: "Rename file1.txt to file2.txt",
+					files: map[string]string{
+						"file2.txt": "line1\nline2\nline3\nline4\nline5 updated\n",
+					},
+					removed: []string{"file1.txt"},
+				},
+				{
+					message: "Update file2.txt",
+					files: map[string]string{
+						"file2.txt": "updated content",
+					},
+				},
+			},
+			renameScore: DefaultRenameScore,
+			wantTrans: []*Transaction{
+				{
+					Files: makeFileSet(FileID(0)),
+				},
+				{
+					Files: makeFileSet(FileID(0)),
+				},
+				{
+					Files: makeFileSet(FileID(0)),
+				},
+			},
+			wantFileMap: map[FileID]FilePath{
+				FileID(0): NewFilePath("file2.txt"),
+			},
+			wantErr: false,
+		},
+		{
+			name: "Rename detection disabled",
+			commits: []mockCommit{
+				{
+					message: "Add file1.txt",
+					files: map[string]string{
+						"file1.txt": "content1",
+					},
+				},
+				{
+					message: "Rename file1.txt to file2.txt",
+					files: map[string]string{
+						"file2.txt": "content1",
+					},
+					removed: []string{"file1.txt"},
+				},
+			},
+			renameScore: 0,
+			wantTrans: []*Transaction{
+				{
+					Files:   makeFileSet(FileID(0), FileID(1)),
+					Deleted: makeFileSet(FileID(0)),
+				},
+				{
+					Files: makeFileSet(FileID(0)),
+				},
+			},
+			wantFileMap: map[FileID]FilePath{
+				FileID(0): NewFilePath("file1.txt"),
+				FileID(1): NewFilePath("file2.txt"),
+			},
+			wantErr: false,
+		},
+		{
+			name: "Subdirectory",
+			commits: []mockCommit{
+				{
+					message: "Add files",
+					files: map[string]string{
+						"svc/a.go":   "content1",
+						"svc/b.go":   "content2",
+						"other/c.go": "content3",
+					},
+				},
+				{
+					message: "Update other/c.go",
+					files: map[string]string{
+						"other/c.go": "updated content",
+					},
+				},
+				{
+					message: "Update svc/a.go and other/c.go",
+					files: map[string]string{
+						"svc/a.go":   "updated content",
+						"other/c.go": "updated content again",
+					},
+				},
+			},
+			subdirectory: "svc",
+			wantTrans: []*Transaction{
+				{
+					Files: makeFileSet(FileID(0)),
+				},
+				{
+					Files: makeFileSet(FileID(0), FileID(1)),
+				},
+			},
+			wantFileMap: map[FileID]FilePath{
+				FileID(0): NewFilePath("a.go"),
+				FileID(1): NewFilePath("b.go"),
+			},
+			wantErr: false,
+		},
 	}
 
 	for _, tt := range tests {
@@ -205,8 +362,12 @@ func TestGitRepository_GetTransactions(t *testing.T) {
 
 			// Create test target object
 			r := &GitRepository{
+				gitConfig: gitConfig{
+					renameScore: tt.renameScore,
+				},
 				repo: repo,
 			}
+			WithSubdirectory(tt.subdirectory)(&r.gitConfig)
 
 			// Execute test
 			gotTrans, gotFileMap, err := r.GetTransactions()
@@ -224,6 +385,7 @@ func TestGitRepository_GetTransactions(t *testing.T) {
 			// Compare each transaction
 			for i := 0; i < len(tt.wantTrans); i++ {
 				assertSetEqual(t, tt.wantTrans[i].Files, gotTrans[i].Files, "Files of transaction %d", i)
+				assertSetEqual(t, tt.wantTrans[i].Deleted, gotTrans[i].Deleted, "Deleted files of transaction %d", i)
 			}
 
 			// Compare file map
@@ -231,3 +393,281 @@ func TestGitRepository_GetTransactions(t *testing.T) {
 		})
 	}
 }
+
+func TestGitRepository_GetTransactions_Refs(t *testing.T) {
+	t.Parallel()
+
+	// master: A(file1) - B(file2)
+	// feature: A(file1) - C(file3)
+	repo, err := createMockRepo([]mockCommit{
+		{message: "A", files: map[string]string{"file1.txt": "content1"}},
+	})
+	if err != nil {
+		t.Fatalf("failed to create mock repo: %v", err)
+	}
+	wt, err := repo.Worktree()
+	if err != nil {
+		t.Fatalf("failed to get worktree: %v", err)
+	}
+	head, err := repo.Head()
+	if err != nil {
+		t.Fatalf("failed to get head: %v", err)
+	}
+
+	commitFile := func(path string, when time.Time) {
+		f, err := wt.Filesystem.Create(path)
+		if err != nil {
+			t.Fatalf("failed to create file: %v", err)
+		}
+		if _, err := f.Write([]byte(path)); err != nil {
+			t.Fatalf("failed to write content: %v", err)
+		}
+		f.Close()
+		if _, err := wt.Add(path); err != nil {
+			t.Fatalf("failed to add file: %v", err)
+		}
+		_, err = wt.Commit(path, &git.CommitOptions{
+			Author: &object.Signature{Name: "Test User", Email: "test@example.com", When: when},
+		})
+		if err != nil {
+			t.Fatalf("failed to commit: %v", err)
+		}
+	}
+
+	now := time.Now()
+	commitFile("file2.txt", now.Add(time.Hour))
+	err = wt.Checkout(&git.CheckoutOptions{
+		Hash:   head.Hash(),
+		Branch: plumbing.NewBranchReferenceName("feature"),
+		Create: true,
+	})
+	if err != nil {
+		t.Fatalf("failed to checkout: %v", err)
+	}
+	commitFile("file3.txt", now.Add(2*time.Hour))
+
+	tests := []struct {
+		name      string
+		refs      []string
+		allRefs   bool
+		wantPaths [][]FilePath
+		wantErr   bool
+	}{
+		{
+			name: "HEAD",
+			wantPaths: [][]FilePath{
+				{NewFilePath("file3.txt")},
+				{NewFilePath("file1.txt")},
+			},
+		},
+		{
+			name: "Single ref",
+			refs: []string{"master"},
+			wantPaths: [][]FilePath{
+				{NewFilePath("file2.txt")},
+				{NewFilePath("file1.txt")},
+			},
+		},
+		{
+			name: "Multiple refs",
+			refs: []string{"master", "feature"},
+			wantPaths: [][]FilePath{
+				{NewFilePath("file3.txt")},
+				{NewFilePath("file2.txt")},
+				{NewFilePath("file1.txt")},
+			},
+		},
+		{
+			name:    "All refs",
+			allRefs: true,
+			wantPaths: [][]FilePath{
+				{NewFilePath("file3.txt")},
+				{NewFilePath("file2.txt")},
+				{NewFilePath("file1.txt")},
+			},
+		},
+		{
+			name:    "Unknown ref",
+			refs:    []string{"unknown"},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := (&GitRepository{repo: repo}).SelectRefs(tt.refs, tt.allRefs)
+
+			gotTrans, gotFileMap, err := r.GetTransactions()
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+
+			gotPaths := make([][]FilePath, 0, len(gotTrans))
+			for _, tx := range gotTrans {
+				paths := make([]FilePath, 0, tx.Files.Len())
+				for fileID := range tx.Files.Iter() {
+					paths = append(paths, gotFileMap[fileID])
+				}
+				gotPaths = append(gotPaths, paths)
+			}
+			assert.Equal(t, tt.wantPaths, gotPaths)
+		})
+	}
+}
+
+func TestGitRepository_Shallow(t *testing.T) {
+	t.Parallel()
+
+	repo, err := createMockRepo([]mockCommit{
+		{message: "A", files: map[string]string{"file1.txt": "content1"}},
+		{message: "B", files: map[string]string{"file2.txt": "content2"}},
+		{message: "C", files: map[string]string{"file3.txt": "content3"}},
+	})
+	if err != nil {
+		t.Fatalf("failed to create mock repo: %v", err)
+	}
+
+	r := &GitRepository{repo: repo}
+
+	depth, shallow, err := r.Depth()
+	assert.NoError(t, err)
+	assert.Equal(t, 0, depth)
+	assert.False(t, shallow)
+
+	// make the root commit the boundary of a shallow clone
+	head, err := repo.Head()
+	if err != nil {
+		t.Fatalf("failed to get head: %v", err)
+	}
+	commit, err := repo.CommitObject(head.Hash())
+	if err != nil {
+		t.Fatalf("failed to get commit: %v", err)
+	}
+	for commit.NumParents() > 0 {
+		commit, err = commit.Parent(0)
+		if err != nil {
+			t.Fatalf("failed to get parent: %v", err)
+		}
+	}
+	if err := repo.Storer.SetShallow([]plumbing.Hash{commit.Hash}); err != nil {
+		t.Fatalf("failed to set shallow: %v", err)
+	}
+
+	// the boundary commit is not counted, as it is not mined
+	depth, shallow, err = r.Depth()
+	assert.NoError(t, err)
+	assert.Equal(t, 2, depth)
+	assert.True(t, shallow)
+
+	// the boundary commit is skipped, since its diff against the empty tree is the whole tree
+	gotTrans, gotFileMap, err := r.GetTransactions()
+	assert.NoError(t, err)
+
+	gotPaths := make([][]FilePath, 0, len(gotTrans))
+	for _, tx := range gotTrans {
+		paths := make([]FilePath, 0, tx.Files.Len())
+		for fileID := range tx.Files.Iter() {
+			paths = append(paths, gotFileMap[fileID])
+		}
+		gotPaths = append(gotPaths, paths)
+	}
+	assert.Equal(t, [][]FilePath{{NewFilePath("file3.txt")}, {NewFilePath("file2.txt")}}, gotPaths)
+}
+
+func TestGitRepository_ChangedFiles(t *testing.T) {
+	t.Parallel()
+
+	repo, err := createMockRepo([]mockCommit{
+		{message: "A", files: map[string]string{"src/a.go": "a", "src/b.go": "b", "c.go": "c"}},
+	})
+	if err != nil {
+		t.Fatalf("failed to create mock repo: %v", err)
+	}
+	wt, err := repo.Worktree()
+	if err != nil {
+		t.Fatalf("failed to get worktree: %v", err)
+	}
+	for path, content := range map[string]string{"src/a.go": "edited", "src/new.go": "new", "c.go": "edited"} {
+		f, err := wt.Filesystem.Create(path)
+		if err != nil {
+			t.Fatalf("failed to create file: %v", err)
+		}
+		if _, err := f.Write([]byte(content)); err != nil {
+			t.Fatalf("failed to write content: %v", err)
+		}
+		f.Close()
+	}
+
+	tests := []struct {
+		name         string
+		subdirectory string
+		want         []FilePath
+	}{
+		{
+			name: "Root",
+			want: []FilePath{NewFilePath("c.go"), NewFilePath("src/a.go"), NewFilePath("src/new.go")},
+		},
+		{
+			name:         "Subdirectory",
+			subdirectory: "src",
+			want:         []FilePath{NewFilePath("a.go"), NewFilePath("new.go")},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := &GitRepository{repo: repo}
+			WithSubdirectory(tt.subdirectory)(&r.gitConfig)
+
+			got, err := r.ChangedFiles()
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestGitRepository_Mailmap(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		commits []mockCommit
+		want    string
+	}{
+		{
+			name: "Mailmap",
+			commits: []mockCommit{
+				{message: "A", files: map[string]string{".mailmap": "Alice <alice@example.com> <alice@old.example.com>\n"}},
+			},
+			want: "alice@example.com",
+		},
+		{
+			name: "No mailmap",
+			commits: []mockCommit{
+				{message: "A", files: map[string]string{"a.go": "a"}},
+			},
+			want: "alice@old.example.com",
+		},
+		{
+			name: "No commits",
+			want: "alice@old.example.com",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			repo, err := createMockRepo(tt.commits)
+			if err != nil {
+				t.Fatalf("failed to create mock repo: %v", err)
+			}
+
+			mailmap, err := (&GitRepository{repo: repo}).Mailmap()
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, mailmap.Email("alice@old.example.com"))
+		})
+	}
+}
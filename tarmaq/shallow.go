@@ -0,0 +1,32 @@
+package tarmaq
+
+import (
+	"bytes"
+	"fmt"
+	"os/exec"
+	"strconv"
+	"strings"
+)
+
+// ShallowReporter is implemented by repositories which can be shallow clones.
+type ShallowReporter interface {
+	// Depth returns the number of commits mined from the available history,
+	// which excludes the boundary commits of a shallow clone, and whether the history is truncated by it.
+	// The history is not walked unless it is truncated, so the number is 0 for a complete history.
+	Depth() (int, bool, error)
+	// Deepen fetches the given number of older commits of a shallow clone from its remote.
+	Deepen(commits int) error
+}
+
+// deepen runs git fetch in dir to deepen a shallow clone.
+func deepen(dir string, commits int) error {
+	var stderr bytes.Buffer
+	cmd := exec.Command("git", "-C", dir, "fetch", "--deepen="+strconv.Itoa(commits))
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("git fetch: %w: %s", err, strings.TrimSpace(stderr.String()))
+	}
+
+	return nil
+}
@@ -0,0 +1,219 @@
+package tarmaq
+
+import (
+	"bufio"
+	"compress/gzip"
+	"encoding/binary"
+	"encoding/gob"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"time"
+
+	"github.com/mazrean/mcp-tarmaq/pkg/collection"
+)
+
+// SnapshotSchemaVersion is the version of the snapshot format.
+// It is incremented on incompatible changes, and snapshots of other versions are rejected.
+// Version 2 has slash separated file paths on all platforms and the committers of transactions.
+const SnapshotSchemaVersion = 2
+
+// snapshotMagic identifies snapshot files.
+var snapshotMagic = []byte("TARMAQ")
+
+// HeadResolver is implemented by repositories whose current revision can be identified.
+type HeadResolver interface {
+	// Head returns the revision the history is mined from.
+	Head() (string, error)
+}
+
+// Snapshot is the mined history of a repository, which can be saved to skip mining on startup.
+type Snapshot struct {
+	SchemaVersion int
+	// Source is the revision of the repository the history was mined from, or empty if unknown.
+	Source       string
+	CreatedAt    time.Time
+	Transactions []*Transaction
+	FileMap      map[FileID]FilePath
+}
+
+// NewSnapshot mines the history of the repository.
+func NewSnapshot(repo Repository) (*Snapshot, error) {
+	transactions, fileMap, err := repo.GetTransactions()
+	if err != nil {
+		return nil, fmt.Errorf("get transactions: %w", err)
+	}
+
+	var source string
+	if resolver, ok := repo.(HeadResolver); ok {
+		source, err = resolver.Head()
+		if err != nil {
+			return nil, fmt.Errorf("get head: %w", err)
+		}
+	}
+
+	return &Snapshot{
+		SchemaVersion: SnapshotSchemaVersion,
+		Source:        source,
+		CreatedAt:     time.Now(),
+		Transactions:  transactions,
+		FileMap:       fileMap,
+	}, nil
+}
+
+// snapshotData is the gob encoded form of Snapshot, since sets cannot be encoded.
+type snapshotData struct {
+	Source       string
+	CreatedAt    time.Time
+	Transactions []*snapshotTransaction
+	FileMap      map[FileID]FilePath
+}
+
+type snapshotTransaction struct {
+	ID        string
+	Time      time.Time
+	Author    string
+	Committer string
+	Message   string
+	Files     []FileID
+	Churn     map[FileID]uint64
+	Renames   []*Rename
+	Deleted   []FileID
+}
+
+// Write writes the snapshot as a gzip compressed gob stream after the magic bytes and the schema version.
+func (s *Snapshot) Write(w io.Writer) error {
+	if _, err := w.Write(snapshotMagic); err != nil {
+		return fmt.Errorf("write magic: %w", err)
+	}
+	if _, err := w.Write(binary.AppendUvarint(nil, SnapshotSchemaVersion)); err != nil {
+		return fmt.Errorf("write schema version: %w", err)
+	}
+
+	data := &snapshotData{
+		Source:       s.Source,
+		CreatedAt:    s.CreatedAt,
+		Transactions: make([]*snapshotTransaction, 0, len(s.Transactions)),
+		FileMap:      s.FileMap,
+	}
+	for _, tx := range s.Transactions {
+		stx := &snapshotTransaction{
+			ID:        tx.ID,
+			Time:      tx.Time,
+			Author:    tx.Author,
+			Committer: tx.Committer,
+			Message:   tx.Message,
+			Files:     slices.Collect(tx.Files.Iter()),
+			Churn:     tx.Churn,
+			Renames:   tx.Renames,
+		}
+		if tx.Deleted != nil {
+			stx.Deleted = slices.Collect(tx.Deleted.Iter())
+		}
+		data.Transactions = append(data.Transactions, stx)
+	}
+
+	gw := gzip.NewWriter(w)
+	if err := gob.NewEncoder(gw).Encode(data); err != nil {
+		return fmt.Errorf("encode snapshot: %w", err)
+	}
+	if err := gw.Close(); err != nil {
+		return fmt.Errorf("close gzip writer: %w", err)
+	}
+
+	return nil
+}
+
+// ReadSnapshot reads a snapshot written by Snapshot.Write.
+func ReadSnapshot(r io.Reader) (*Snapshot, error) {
+	br := bufio.NewReader(r)
+
+	magic := make([]byte, len(snapshotMagic))
+	if _, err := io.ReadFull(br, magic); err != nil || string(magic) != string(snapshotMagic) {
+		return nil, errors.New("not a snapshot file")
+	}
+	version, err := binary.ReadUvarint(br)
+	if err != nil {
+		return nil, fmt.Errorf("read schema version: %w", err)
+	}
+	if version != SnapshotSchemaVersion {
+		return nil, fmt.Errorf("unsupported schema version: %d (expected %d, export the model again)", version, SnapshotSchemaVersion)
+	}
+
+	gr, err := gzip.NewReader(br)
+	if err != nil {
+		return nil, fmt.Errorf("create gzip reader: %w", err)
+	}
+	defer gr.Close()
+
+	var data snapshotData
+	if err := gob.NewDecoder(gr).Decode(&data); err != nil {
+		return nil, fmt.Errorf("decode snapshot: %w", err)
+	}
+
+	snapshot := &Snapshot{
+		SchemaVersion: int(version),
+		Source:        data.Source,
+		CreatedAt:     data.CreatedAt,
+		Transactions:  make([]*Transaction, 0, len(data.Transactions)),
+		FileMap:       data.FileMap,
+	}
+	if snapshot.FileMap == nil {
+		snapshot.FileMap = make(map[FileID]FilePath)
+	}
+	for _, stx := range data.Transactions {
+		tx := &Transaction{
+			ID:        stx.ID,
+			Time:      stx.Time,
+			Author:    stx.Author,
+			Committer: stx.Committer,
+			Message:   stx.Message,
+			Files:     collection.NewSet(stx.Files...),
+			Churn:     stx.Churn,
+			Renames:   stx.Renames,
+		}
+		if len(stx.Deleted) > 0 {
+			tx.Deleted = collection.NewSet(stx.Deleted...)
+		}
+		snapshot.Transactions = append(snapshot.Transactions, tx)
+	}
+
+	return snapshot, nil
+}
+
+// ReadSnapshotFile reads the snapshot at path.
+func ReadSnapshotFile(path string) (*Snapshot, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("open snapshot: %w", err)
+	}
+	defer f.Close()
+
+	return ReadSnapshot(f)
+}
+
+var (
+	_ Repository   = &SnapshotRepository{}
+	_ HeadResolver = &SnapshotRepository{}
+)
+
+// SnapshotRepository serves the history of a snapshot.
+type SnapshotRepository struct {
+	snapshot *Snapshot
+}
+
+func NewSnapshotRepository(snapshot *Snapshot) *SnapshotRepository {
+	return &SnapshotRepository{
+		snapshot: snapshot,
+	}
+}
+
+func (r *SnapshotRepository) GetTransactions() ([]*Transaction, map[FileID]FilePath, error) {
+	return r.snapshot.Transactions, r.snapshot.FileMap, nil
+}
+
+func (r *SnapshotRepository) Head() (string, error) {
+	return r.snapshot.Source, nil
+}
@@ -0,0 +1,104 @@
+package tarmaq
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSnapshot_WriteRead(t *testing.T) {
+	t.Parallel()
+
+	repo := &mockRepository{
+		transactions: []*Transaction{
+			{
+				ID:        "c2",
+				Time:      time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC),
+				Author:    "alice@example.com",
+				Committer: "bob@example.com",
+				Message:   "Rename a.go",
+				Files:     makeFileSet(FileID(0), FileID(1)),
+				Churn:     map[FileID]uint64{FileID(0): 3, FileID(1): 5},
+				Renames: []*Rename{
+					{File: FileID(0), From: NewFilePath("a.go"), To: NewFilePath("x.go")},
+				},
+				Deleted: makeFileSet(FileID(1)),
+			},
+			{
+				ID:     "c1",
+				Time:   time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+				Author: "bob@example.com",
+				Files:  makeFileSet(FileID(0), FileID(1)),
+			},
+		},
+		fileMap: map[FileID]FilePath{
+			FileID(0): NewFilePath("x.go"),
+			FileID(1): NewFilePath("b.go"),
+		},
+	}
+
+	snapshot, err := NewSnapshot(repo)
+	assert.NoError(t, err)
+	assert.Equal(t, SnapshotSchemaVersion, snapshot.SchemaVersion)
+
+	var buf bytes.Buffer
+	assert.NoError(t, snapshot.Write(&buf))
+
+	got, err := ReadSnapshot(&buf)
+	assert.NoError(t, err)
+
+	assert.Equal(t, snapshot.SchemaVersion, got.SchemaVersion)
+	assert.Equal(t, snapshot.Source, got.Source)
+	assert.True(t, snapshot.CreatedAt.Equal(got.CreatedAt))
+	assert.Equal(t, repo.fileMap, got.FileMap)
+	assert.Equal(t, len(repo.transactions), len(got.Transactions))
+	for i := 0; i < len(repo.transactions) && i < len(got.Transactions); i++ {
+		want, tx := repo.transactions[i], got.Transactions[i]
+		assert.Equal(t, want.ID, tx.ID)
+		assert.True(t, want.Time.Equal(tx.Time))
+		assert.Equal(t, want.Author, tx.Author)
+		assert.Equal(t, want.Committer, tx.Committer)
+		assert.Equal(t, want.Message, tx.Message)
+		assertSetEqual(t, want.Files, tx.Files, "Files of transaction %d", i)
+		assertSetEqual(t, want.Deleted, tx.Deleted, "Deleted files of transaction %d", i)
+		assert.Equal(t, want.Churn, tx.Churn)
+		assert.Equal(t, want.Renames, tx.Renames)
+	}
+}
+
+func TestReadSnapshot_Invalid(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name string
+		data []byte
+	}{
+		{
+			name: "Not a snapshot",
+			data: []byte("hello world"),
+		},
+		{
+			name: "Unsupported schema version",
+			data: append([]byte("TARMAQ"), 0x7f),
+		},
+		{
+			name: "Version without committers",
+			data: append([]byte("TARMAQ"), 1),
+		},
+		{
+			name: "Truncated",
+			data: append([]byte("TARMAQ"), SnapshotSchemaVersion),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			_, err := ReadSnapshot(bytes.NewReader(tt.data))
+			assert.Error(t, err)
+		})
+	}
+}
@@ -0,0 +1,49 @@
+package tarmaq
+
+import (
+	"time"
+
+	"github.com/mazrean/mcp-tarmaq/pkg/collection"
+)
+
+// Stats summarizes the mined history of a repository.
+type Stats struct {
+	Transactions int
+	// Files is the number of files in the history, including removed files.
+	Files        int
+	RemovedFiles int
+	Authors      int
+	// FirstCommit and LastCommit are zero if there are no transactions.
+	FirstCommit time.Time
+	LastCommit  time.Time
+}
+
+// NewStats summarizes the transactions.
+func NewStats(transactions []*Transaction, fileMap map[FileID]FilePath) *Stats {
+	files := collection.NewSet[FileID]()
+	authors := collection.NewSet[string]()
+	stats := &Stats{
+		Transactions: len(transactions),
+	}
+	for _, tx := range transactions {
+		for fileID := range tx.Files.Iter() {
+			if _, ok := fileMap[fileID]; ok {
+				files.Add(fileID)
+			}
+		}
+		if tx.Author != "" {
+			authors.Add(tx.Author)
+		}
+		if stats.FirstCommit.IsZero() || tx.Time.Before(stats.FirstCommit) {
+			stats.FirstCommit = tx.Time
+		}
+		if tx.Time.After(stats.LastCommit) {
+			stats.LastCommit = tx.Time
+		}
+	}
+	stats.Files = files.Len()
+	stats.RemovedFiles = RemovedFiles(transactions).Len()
+	stats.Authors = authors.Len()
+
+	return stats
+}
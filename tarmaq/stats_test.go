@@ -0,0 +1,67 @@
+package tarmaq
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewStats(t *testing.T) {
+	t.Parallel()
+
+	fileMap := map[FileID]FilePath{
+		FileID(0): NewFilePath("a.go"),
+		FileID(1): NewFilePath("b.go"),
+		FileID(2): NewFilePath("c.go"),
+	}
+
+	tests := []struct {
+		name         string
+		transactions []*Transaction
+		want         *Stats
+	}{
+		{
+			name: "Empty history",
+			want: &Stats{},
+		},
+		{
+			name: "Transactions",
+			transactions: []*Transaction{
+				{
+					Time:    time.Date(2024, 1, 3, 0, 0, 0, 0, time.UTC),
+					Author:  "alice@example.com",
+					Files:   makeFileSet(FileID(0), FileID(2)),
+					Deleted: makeFileSet(FileID(2)),
+				},
+				{
+					Time:   time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC),
+					Author: "bob@example.com",
+					Files:  makeFileSet(FileID(1)),
+				},
+				{
+					Time:   time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+					Author: "alice@example.com",
+					Files:  makeFileSet(FileID(0), FileID(1), FileID(2)),
+				},
+			},
+			want: &Stats{
+				Transactions: 3,
+				Files:        3,
+				RemovedFiles: 1,
+				Authors:      2,
+				FirstCommit:  time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+				LastCommit:   time.Date(2024, 1, 3, 0, 0, 0, 0, time.UTC),
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			got := NewStats(tt.transactions, fileMap)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
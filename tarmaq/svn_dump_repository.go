@@ -0,0 +1,463 @@
+package tarmaq
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/mazrean/mcp-tarmaq/pkg/collection"
+)
+
+var _ Repository = &SVNDumpRepository{}
+
+// SVNDumpRepository reads transactions from a dump file of a Subversion repository created by `svnadmin dump`.
+// A copy of a file or directory whose source is deleted in the same revision is tracked as a rename.
+type SVNDumpRepository struct {
+	path             string
+	transactionLimit int
+	// subdirectory limits mining to files under it, with paths relative to it.
+	subdirectory string
+}
+
+type SVNDumpOption func(*SVNDumpRepository)
+
+// WithSVNSubdirectory limits mining to files under the directory (e.g. trunk), with paths relative to it.
+func WithSVNSubdirectory(dir string) SVNDumpOption {
+	return func(r *SVNDumpRepository) {
+		r.subdirectory = cleanSubdirectory(dir)
+	}
+}
+
+func NewSVNDumpRepository(path string, transactionLimit int, options ...SVNDumpOption) (*SVNDumpRepository, error) {
+	if _, err := os.Stat(path); err != nil {
+		return nil, fmt.Errorf("stat dump file: %w", err)
+	}
+
+	r := &SVNDumpRepository{
+		path:             path,
+		transactionLimit: transactionLimit,
+	}
+	for _, option := range options {
+		option(r)
+	}
+
+	return r, nil
+}
+
+func (r *SVNDumpRepository) GetTransactions() ([]*Transaction, map[FileID]FilePath, error) {
+	f, err := os.Open(r.path)
+	if err != nil {
+		return nil, nil, fmt.Errorf("open dump file: %w", err)
+	}
+	defer f.Close()
+
+	revisions, err := readSVNDump(bufio.NewReader(f))
+	if err != nil {
+		return nil, nil, fmt.Errorf("read dump file: %w", err)
+	}
+
+	commits, err := svnCommits(revisions)
+	if err != nil {
+		return nil, nil, fmt.Errorf("read dump file: %w", err)
+	}
+
+	history := newHistoryBuilder(func(name string) (string, bool) {
+		return scopePath(r.subdirectory, name)
+	})
+	var transactions []*Transaction
+	// the history is built from the newest commit to identify files by their latest paths
+	for _, commit := range slices.Backward(commits) {
+		tx := &Transaction{
+			ID:      commit.id,
+			Time:    commit.time,
+			Author:  commit.author,
+			Message: commit.message,
+			Files:   collection.NewSet[FileID](),
+		}
+		for _, change := range commit.changes {
+			history.add(tx, change.from, change.to)
+		}
+
+		if tx.Files.Len() > 0 {
+			transactions = append(transactions, tx)
+			if r.transactionLimit != 0 && len(transactions) >= r.transactionLimit {
+				break
+			}
+		}
+	}
+
+	return transactions, history.fileMap, nil
+}
+
+type svnRevision struct {
+	number int
+	props  map[string]string
+	nodes  []*svnNode
+}
+
+type svnNode struct {
+	path         string
+	kind         string
+	action       string
+	copyFromPath string
+	copyFromRev  int
+}
+
+// readSVNDump reads the revisions of a dump file, ignoring the contents of files.
+func readSVNDump(reader *bufio.Reader) ([]*svnRevision, error) {
+	var revisions []*svnRevision
+	for {
+		headers, err := readSVNDumpHeaders(reader)
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		contentLength, err := svnDumpLength(headers, "Content-length")
+		if err != nil {
+			return nil, err
+		}
+		propLength, err := svnDumpLength(headers, "Prop-content-length")
+		if err != nil {
+			return nil, err
+		}
+		if propLength > contentLength {
+			// old dumps may omit Content-length
+			contentLength = propLength
+		}
+		// only the properties are used, so texts, which can be large binaries, are skipped
+		content := make([]byte, propLength)
+		if _, err := io.ReadFull(reader, content); err != nil {
+			return nil, fmt.Errorf("read properties: %w", err)
+		}
+		if _, err := io.CopyN(io.Discard, reader, int64(contentLength-propLength)); err != nil {
+			return nil, fmt.Errorf("skip text: %w", err)
+		}
+
+		switch {
+		case headers["Revision-number"] != "":
+			number, err := strconv.Atoi(headers["Revision-number"])
+			if err != nil {
+				return nil, fmt.Errorf("parse revision number: %w", err)
+			}
+
+			props, err := parseSVNProps(string(content))
+			if err != nil {
+				return nil, fmt.Errorf("parse properties of r%d: %w", number, err)
+			}
+
+			revisions = append(revisions, &svnRevision{
+				number: number,
+				props:  props,
+			})
+		case headers["Node-path"] != "":
+			if len(revisions) == 0 {
+				return nil, fmt.Errorf("node %s before revision", headers["Node-path"])
+			}
+
+			node := &svnNode{
+				path:         strings.Trim(headers["Node-path"], "/"),
+				kind:         headers["Node-kind"],
+				action:       headers["Node-action"],
+				copyFromPath: strings.Trim(headers["Node-copyfrom-path"], "/"),
+			}
+			if rev := headers["Node-copyfrom-rev"]; rev != "" {
+				node.copyFromRev, err = strconv.Atoi(rev)
+				if err != nil {
+					return nil, fmt.Errorf("parse copy source revision of %s: %w", node.path, err)
+				}
+			}
+
+			revision := revisions[len(revisions)-1]
+			revision.nodes = append(revision.nodes, node)
+		}
+	}
+
+	return revisions, nil
+}
+
+// readSVNDumpHeaders reads the headers of a record, skipping blank lines before it.
+func readSVNDumpHeaders(reader *bufio.Reader) (map[string]string, error) {
+	headers := make(map[string]string)
+	for {
+		line, err := reader.ReadString('\n')
+		line = strings.TrimRight(line, "\r\n")
+		if line == "" {
+			if len(headers) > 0 {
+				return headers, nil
+			}
+			if err != nil {
+				return nil, err
+			}
+			continue
+		}
+
+		key, value, ok := strings.Cut(line, ": ")
+		if !ok {
+			return nil, fmt.Errorf("malformed header: %q", line)
+		}
+		headers[key] = value
+
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				return headers, nil
+			}
+			return nil, err
+		}
+	}
+}
+
+func svnDumpLength(headers map[string]string, key string) (int, error) {
+	value, ok := headers[key]
+	if !ok {
+		return 0, nil
+	}
+
+	length, err := strconv.Atoi(value)
+	if err != nil {
+		return 0, fmt.Errorf("parse %s: %w", key, err)
+	}
+
+	return length, nil
+}
+
+// parseSVNProps parses properties in the "K <length>\n<key>\nV <length>\n<value>\n" format terminated by PROPS-END.
+func parseSVNProps(content string) (map[string]string, error) {
+	props := make(map[string]string)
+
+	readValue := func(prefix byte) (string, error) {
+		line, rest, ok := strings.Cut(content, "\n")
+		if !ok || len(line) < 2 || line[0] != prefix {
+			return "", fmt.Errorf("malformed property: %q", line)
+		}
+		length, err := strconv.Atoi(line[2:])
+		if err != nil || length > len(rest) {
+			return "", fmt.Errorf("malformed property length: %q", line)
+		}
+
+		value := rest[:length]
+		content = strings.TrimPrefix(rest[length:], "\n")
+		return value, nil
+	}
+
+	for len(content) > 0 && !strings.HasPrefix(content, "PROPS-END") {
+		if content[0] == 'D' {
+			// deleted property of a delta dump
+			if _, err := readValue('D'); err != nil {
+				return nil, err
+			}
+			continue
+		}
+
+		key, err := readValue('K')
+		if err != nil {
+			return nil, err
+		}
+		value, err := readValue('V')
+		if err != nil {
+			return nil, err
+		}
+		props[key] = value
+	}
+
+	return props, nil
+}
+
+type svnCommit struct {
+	id      string
+	time    time.Time
+	author  string
+	message string
+	changes []fileChange
+}
+
+// svnLifetime is the range of revisions a file exists in. end is 0 while it exists.
+type svnLifetime struct {
+	start int
+	end   int
+}
+
+// svnTree records the lifetimes of files to resolve copies of directories from past revisions.
+type svnTree struct {
+	lifetimes map[string][]*svnLifetime
+}
+
+func (t *svnTree) exists(path string, revision int) bool {
+	for _, lifetime := range slices.Backward(t.lifetimes[path]) {
+		if lifetime.start <= revision {
+			return lifetime.end == 0 || lifetime.end > revision
+		}
+	}
+
+	return false
+}
+
+// files returns the files at or under path in the revision.
+func (t *svnTree) files(path string, revision int) []string {
+	if t.exists(path, revision) {
+		return []string{path}
+	}
+
+	var files []string
+	for file := range t.lifetimes {
+		if (file == path || strings.HasPrefix(file, path+"/") || path == "") && t.exists(file, revision) {
+			files = append(files, file)
+		}
+	}
+	slices.Sort(files)
+
+	return files
+}
+
+func (t *svnTree) add(path string, revision int) {
+	t.lifetimes[path] = append(t.lifetimes[path], &svnLifetime{start: revision})
+}
+
+func (t *svnTree) delete(path string, revision int) {
+	lifetimes := t.lifetimes[path]
+	if len(lifetimes) > 0 && lifetimes[len(lifetimes)-1].end == 0 {
+		lifetimes[len(lifetimes)-1].end = revision
+	}
+}
+
+// svnCommits converts revisions to commits ordered from the oldest, with the changes of files.
+func svnCommits(revisions []*svnRevision) ([]*svnCommit, error) {
+	tree := &svnTree{lifetimes: make(map[string][]*svnLifetime)}
+
+	commits := make([]*svnCommit, 0, len(revisions))
+	for _, revision := range revisions {
+		previous := revision.number - 1
+
+		deletedPaths := collection.NewSet[string]()
+		for _, node := range revision.nodes {
+			if node.action == "delete" || node.action == "replace" {
+				deletedPaths.Add(node.path)
+			}
+		}
+		// sourceDeleted reports whether the path or its parent directory is deleted in the revision
+		sourceDeleted := func(path string) bool {
+			for dir := path; dir != ""; dir = parentDir(dir) {
+				if deletedPaths.Contains(dir) {
+					return true
+				}
+			}
+			return false
+		}
+
+		var changes []fileChange
+		renamed := collection.NewSet[string]()
+		deleteFiles := func(path string) {
+			for _, file := range tree.files(path, previous) {
+				tree.delete(file, revision.number)
+				if !renamed.Contains(file) {
+					changes = append(changes, fileChange{from: file})
+				}
+			}
+		}
+
+		// copies are resolved first, so that the deletions of their sources are recorded as renames
+		for _, node := range revision.nodes {
+			if node.copyFromPath == "" || (node.action != "add" && node.action != "replace") {
+				continue
+			}
+
+			for _, source := range tree.files(node.copyFromPath, node.copyFromRev) {
+				target := node.path + strings.TrimPrefix(source, node.copyFromPath)
+				change := fileChange{to: target}
+				if tree.exists(source, previous) && sourceDeleted(source) && !renamed.Contains(source) {
+					change.from = source
+					renamed.Add(source)
+				}
+				changes = append(changes, change)
+			}
+		}
+
+		for _, node := range revision.nodes {
+			switch node.action {
+			case "delete":
+				deleteFiles(node.path)
+			case "replace":
+				deleteFiles(node.path)
+				if node.copyFromPath == "" && node.kind == "file" {
+					changes = append(changes, fileChange{to: node.path})
+				}
+			case "add":
+				if node.copyFromPath == "" && node.kind == "file" {
+					changes = append(changes, fileChange{to: node.path})
+				}
+			case "change":
+				if node.kind == "file" || tree.exists(node.path, previous) {
+					changes = append(changes, fileChange{from: node.path, to: node.path})
+				}
+			}
+		}
+
+		// a file replaced by itself is a modification
+		added := collection.NewSet[string]()
+		for _, change := range changes {
+			if change.to != "" {
+				added.Add(change.to)
+			}
+		}
+		merged := changes[:0]
+		for _, change := range changes {
+			if change.to == "" && added.Contains(change.from) {
+				continue
+			}
+			if change.from == "" && change.to != "" && tree.exists(change.to, previous) && deletedPaths.Contains(change.to) {
+				change.from = change.to
+			}
+			merged = append(merged, change)
+		}
+
+		for _, change := range merged {
+			if change.to != "" && !tree.exists(change.to, revision.number) {
+				tree.add(change.to, revision.number)
+			}
+		}
+
+		if len(merged) == 0 {
+			continue
+		}
+
+		var t time.Time
+		if date, ok := revision.props["svn:date"]; ok {
+			var err error
+			t, err = time.Parse(time.RFC3339Nano, date)
+			if err != nil {
+				return nil, fmt.Errorf("parse date of r%d: %w", revision.number, err)
+			}
+		} else {
+			slog.Warn("revision has no date",
+				slog.Int("revision", revision.number),
+			)
+		}
+		commits = append(commits, &svnCommit{
+			id:      "r" + strconv.Itoa(revision.number),
+			time:    t,
+			author:  revision.props["svn:author"],
+			message: revision.props["svn:log"],
+			changes: merged,
+		})
+	}
+
+	return commits, nil
+}
+
+func parentDir(path string) string {
+	i := strings.LastIndex(path, "/")
+	if i < 0 {
+		return ""
+	}
+
+	return path[:i]
+}
@@ -0,0 +1,178 @@
+package tarmaq
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// svnDumpProps formats properties as in a dump file.
+func svnDumpProps(kv ...string) string {
+	var sb strings.Builder
+	for i := 0; i+1 < len(kv); i += 2 {
+		fmt.Fprintf(&sb, "K %d\n%s\nV %d\n%s\n", len(kv[i]), kv[i], len(kv[i+1]), kv[i+1])
+	}
+	sb.WriteString("PROPS-END\n")
+
+	return sb.String()
+}
+
+func svnDumpRevision(number int, author string, date string) string {
+	props := svnDumpProps("svn:author", author, "svn:date", date, "svn:log", fmt.Sprintf("r%d", number))
+	return fmt.Sprintf("Revision-number: %d\nProp-content-length: %d\nContent-length: %d\n\n%s\n", number, len(props), len(props), props)
+}
+
+// svnDumpNode formats a node record. headers are added to Node-path, and files have a text content.
+func svnDumpNode(path string, headers ...string) string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "Node-path: %s\n", path)
+	for _, header := range headers {
+		sb.WriteString(header + "\n")
+	}
+	if strings.Contains(strings.Join(headers, "\n"), "Node-kind: file") {
+		props := svnDumpProps()
+		text := "content of " + path + "\n"
+		fmt.Fprintf(&sb, "Prop-content-length: %d\nText-content-length: %d\nContent-length: %d\n\n%s%s", len(props), len(text), len(props)+len(text), props, text)
+	}
+	sb.WriteString("\n\n")
+
+	return sb.String()
+}
+
+func TestSVNDumpRepository_GetTransactions(t *testing.T) {
+	t.Parallel()
+
+	dump := "SVN-fs-dump-format-version: 2\n\nUUID: 7bf7a5ef-cabf-0310-b7d4-93df341afa7e\n\n" +
+		"Revision-number: 0\nProp-content-length: 56\nContent-length: 56\n\n" +
+		svnDumpProps("svn:date", "2024-01-01T00:00:00.000000Z") + "\n" +
+		svnDumpRevision(1, "alice", "2024-01-01T00:00:00.000000Z") +
+		svnDumpNode("trunk", "Node-kind: dir", "Node-action: add") +
+		svnDumpNode("trunk/a.c", "Node-kind: file", "Node-action: add") +
+		svnDumpNode("trunk/b.c", "Node-kind: file", "Node-action: add") +
+		svnDumpRevision(2, "bob", "2024-01-02T00:00:00.000000Z") +
+		svnDumpNode("trunk/a.c", "Node-kind: file", "Node-action: change") +
+		svnDumpNode("trunk/b.c", "Node-kind: file", "Node-action: change") +
+		// rename a.c to x.c
+		svnDumpRevision(3, "alice", "2024-01-03T00:00:00.000000Z") +
+		svnDumpNode("trunk/x.c", "Node-kind: file", "Node-action: add", "Node-copyfrom-rev: 2", "Node-copyfrom-path: trunk/a.c") +
+		svnDumpNode("trunk/a.c", "Node-action: delete") +
+		// create a branch
+		svnDumpRevision(4, "alice", "2024-01-04T00:00:00.000000Z") +
+		svnDumpNode("branches/dev", "Node-kind: dir", "Node-action: add", "Node-copyfrom-rev: 3", "Node-copyfrom-path: trunk") +
+		svnDumpRevision(5, "bob", "2024-01-05T00:00:00.000000Z") +
+		svnDumpNode("trunk/x.c", "Node-kind: file", "Node-action: change") +
+		svnDumpNode("trunk/b.c", "Node-action: delete") +
+		// move a directory
+		svnDumpRevision(6, "bob", "2024-01-06T00:00:00.000000Z") +
+		svnDumpNode("trunk/lib", "Node-kind: dir", "Node-action: add") +
+		svnDumpNode("trunk/lib/l.c", "Node-kind: file", "Node-action: add") +
+		svnDumpNode("trunk/x.c", "Node-kind: file", "Node-action: change") +
+		svnDumpRevision(7, "bob", "2024-01-07T00:00:00.000000Z") +
+		svnDumpNode("trunk/pkg", "Node-kind: dir", "Node-action: add", "Node-copyfrom-rev: 6", "Node-copyfrom-path: trunk/lib") +
+		svnDumpNode("trunk/lib", "Node-action: delete")
+
+	tests := []struct {
+		name             string
+		subdirectory     string
+		transactionLimit int
+		wantIDs          []string
+		wantTrans        []*Transaction
+		wantFileMap      map[FileID]FilePath
+	}{
+		{
+			name:         "Trunk",
+			subdirectory: "trunk",
+			wantIDs:      []string{"r7", "r6", "r5", "r3", "r2", "r1"},
+			wantTrans: []*Transaction{
+				{Files: makeFileSet(FileID(0))},
+				{Files: makeFileSet(FileID(0), FileID(1))},
+				{Files: makeFileSet(FileID(1), FileID(2)), Deleted: makeFileSet(FileID(2))},
+				{Files: makeFileSet(FileID(1))},
+				{Files: makeFileSet(FileID(1), FileID(2))},
+				{Files: makeFileSet(FileID(1), FileID(2))},
+			},
+			wantFileMap: map[FileID]FilePath{
+				FileID(0): NewFilePath("pkg/l.c"),
+				FileID(1): NewFilePath("x.c"),
+				FileID(2): NewFilePath("b.c"),
+			},
+		},
+		{
+			name:             "Whole repository",
+			transactionLimit: 4,
+			wantIDs:          []string{"r7", "r6", "r5", "r4"},
+			wantTrans: []*Transaction{
+				{Files: makeFileSet(FileID(0))},
+				{Files: makeFileSet(FileID(0), FileID(1))},
+				{Files: makeFileSet(FileID(1), FileID(2)), Deleted: makeFileSet(FileID(2))},
+				{Files: makeFileSet(FileID(3), FileID(4))},
+			},
+			wantFileMap: map[FileID]FilePath{
+				FileID(0): NewFilePath("trunk/pkg/l.c"),
+				FileID(1): NewFilePath("trunk/x.c"),
+				FileID(2): NewFilePath("trunk/b.c"),
+				FileID(3): NewFilePath("branches/dev/b.c"),
+				FileID(4): NewFilePath("branches/dev/x.c"),
+			},
+		},
+	}
+
+	path := filepath.Join(t.TempDir(), "repo.dump")
+	if err := os.WriteFile(path, []byte(dump), 0o600); err != nil {
+		t.Fatalf("failed to write dump file: %v", err)
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			r, err := NewSVNDumpRepository(path, tt.transactionLimit, WithSVNSubdirectory(tt.subdirectory))
+			assert.NoError(t, err)
+
+			gotTrans, gotFileMap, err := r.GetTransactions()
+			assert.NoError(t, err)
+
+			gotIDs := make([]string, 0, len(gotTrans))
+			for _, tx := range gotTrans {
+				gotIDs = append(gotIDs, tx.ID)
+			}
+			assert.Equal(t, tt.wantIDs, gotIDs)
+
+			for i := 0; i < len(tt.wantTrans) && i < len(gotTrans); i++ {
+				assertSetEqual(t, tt.wantTrans[i].Files, gotTrans[i].Files, "Files of transaction %d", i)
+				assertSetEqual(t, tt.wantTrans[i].Deleted, gotTrans[i].Deleted, "Deleted files of transaction %d", i)
+			}
+			assert.Equal(t, tt.wantFileMap, gotFileMap, "File map does not match")
+
+			if len(gotTrans) > 0 {
+				assert.Equal(t, "bob", gotTrans[0].Author)
+				assert.Equal(t, "r7", gotTrans[0].Message)
+				assert.True(t, time.Date(2024, 1, 7, 0, 0, 0, 0, time.UTC).Equal(gotTrans[0].Time))
+			}
+		})
+	}
+}
+
+func TestSVNDumpRepository_InvalidDate(t *testing.T) {
+	t.Parallel()
+
+	dump := "SVN-fs-dump-format-version: 2\n\n" +
+		svnDumpRevision(1, "alice", "yesterday") +
+		svnDumpNode("a.c", "Node-kind: file", "Node-action: add")
+
+	path := filepath.Join(t.TempDir(), "repo.dump")
+	if err := os.WriteFile(path, []byte(dump), 0o600); err != nil {
+		t.Fatalf("failed to write dump file: %v", err)
+	}
+
+	r, err := NewSVNDumpRepository(path, 0)
+	assert.NoError(t, err)
+
+	_, _, err = r.GetTransactions()
+	assert.ErrorContains(t, err, "parse date of r1")
+}
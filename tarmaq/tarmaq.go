@@ -1,8 +1,10 @@
 package tarmaq
 
 import (
+	"errors"
 	"log/slog"
 	"slices"
+	"strings"
 
 	"github.com/mazrean/mcp-tarmaq/pkg/collection"
 )
@@ -25,26 +27,207 @@ type Result struct {
 	Path       FilePath
 	Confidence float64
 	Support    uint64
+	// Deleted is true if the file no longer exists.
+	Deleted bool
+	// Antecedents are the query files whose changes imply the change of the file.
+	Antecedents []FilePath
 }
 
-func (t *Tarmaq) Execute(files []FilePath) ([]*Result, error) {
-	transactions, fileMap, err := t.Repository.GetTransactions()
+// Diagnostics reports how the query of Tarmaq.Execute was interpreted.
+type Diagnostics struct {
+	// MatchedFiles are the query files found in the history.
+	MatchedFiles []FilePath
+	// UnknownFiles are the query files not found in the history, which are ignored.
+	UnknownFiles []FilePath
+	// CaseFolded are the query files matched to the history ignoring case.
+	CaseFolded []*PathMatch
+	// Transactions is the number of transactions in the history.
+	Transactions int
+	// TxFilters are the numbers of transactions surviving each filter, in the order of application.
+	TxFilters []*TxFilterCount
+}
+
+// PathMatch is a query file matched to a different path in the history.
+type PathMatch struct {
+	Query FilePath
+	Path  FilePath
+}
+
+// History is the history mined by Tarmaq.Execute, so that other analyses can reuse it without mining again.
+type History struct {
+	// Transactions are the transactions before any filter is applied.
+	Transactions []*Transaction
+	FileMap      map[FileID]FilePath
+}
+
+type TxFilterCount struct {
+	// Filter is the type name of the filter.
+	Filter       string
+	Transactions int
+}
+
+type executeConfig struct {
+	excludeDeleted bool
+	refs           []string
+	allRefs        bool
+	diagnostics    *Diagnostics
+	ignoreCase     bool
+	txFilters      []TxFilter
+	history        *History
+}
+
+type ExecuteOption func(*executeConfig)
+
+// ExcludeDeleted drops results whose files no longer exist.
+func ExcludeDeleted() ExecuteOption {
+	return func(c *executeConfig) {
+		c.excludeDeleted = true
+	}
+}
+
+// OnRefs mines the history reachable from the refs.
+// The repository must implement RefSelector.
+func OnRefs(refs ...string) ExecuteOption {
+	return func(c *executeConfig) {
+		c.refs = refs
+	}
+}
+
+// OnAllRefs mines the history reachable from all branches.
+// The repository must implement RefSelector.
+func OnAllRefs() ExecuteOption {
+	return func(c *executeConfig) {
+		c.allRefs = true
+	}
+}
+
+// WithDiagnostics reports the interpretation of the query to d.
+func WithDiagnostics(d *Diagnostics) ExecuteOption {
+	return func(c *executeConfig) {
+		c.diagnostics = d
+	}
+}
+
+// IgnoreCase matches the query files not found in the history to the files differing only in case,
+// as on case-insensitive file systems. Files matching several files in the history are not matched.
+func IgnoreCase() ExecuteOption {
+	return func(c *executeConfig) {
+		c.ignoreCase = true
+	}
+}
+
+// WithHistory reports the mined history to h.
+func WithHistory(h *History) ExecuteOption {
+	return func(c *executeConfig) {
+		c.history = h
+	}
+}
+
+// WithTxFilters applies the filters to the history before the filters of the Tarmaq.
+func WithTxFilters(filters ...TxFilter) ExecuteOption {
+	return func(c *executeConfig) {
+		c.txFilters = append(c.txFilters, filters...)
+	}
+}
+
+func (t *Tarmaq) Execute(files []FilePath, options ...ExecuteOption) ([]*Result, error) {
+	config := &executeConfig{}
+	for _, option := range options {
+		option(config)
+	}
+
+	repo := t.Repository
+	if len(config.refs) > 0 || config.allRefs {
+		selector, ok := repo.(RefSelector)
+		if !ok {
+			return nil, errors.New("repository does not support ref selection")
+		}
+		repo = selector.SelectRefs(config.refs, config.allRefs)
+	}
+
+	transactions, fileMap, err := repo.GetTransactions()
 	if err != nil {
 		return nil, err
 	}
 
-	query := t.createQuery(files, fileMap)
+	if config.history != nil {
+		config.history.Transactions = transactions
+		config.history.FileMap = fileMap
+	}
+
+	removed := RemovedFiles(transactions)
 
-	for _, filter := range t.TxFilters {
+	var folded []*PathMatch
+	if config.ignoreCase {
+		files, folded = FoldCase(files, fileMap)
+	}
+
+	query, unknown := t.createQuery(files, fileMap)
+	if config.diagnostics != nil {
+		config.diagnostics.MatchedFiles = slices.DeleteFunc(slices.Clone(files), func(file FilePath) bool {
+			return slices.Contains(unknown, file)
+		})
+		config.diagnostics.UnknownFiles = unknown
+		config.diagnostics.CaseFolded = folded
+		config.diagnostics.Transactions = len(transactions)
+	}
+
+	for _, filter := range slices.Concat(config.txFilters, t.TxFilters) {
 		transactions = filter.Filter(transactions, query)
+		if config.diagnostics != nil {
+			config.diagnostics.TxFilters = append(config.diagnostics.TxFilters, &TxFilterCount{
+				Filter:       txFilterName(filter),
+				Transactions: len(transactions),
+			})
+		}
 	}
 
 	rules := t.Extractor.Extract(transactions, query)
 
-	return t.createResults(rules, fileMap), nil
+	results := t.createResults(rules, fileMap, removed)
+	if config.excludeDeleted {
+		results = slices.DeleteFunc(results, func(result *Result) bool {
+			return result.Deleted
+		})
+	}
+
+	return results, nil
 }
 
-func (t *Tarmaq) createQuery(paths []FilePath, fileMap map[FileID]FilePath) *Query {
+// FoldCase replaces the paths not found in the file map with the only path in it differing in case.
+// It returns the replaced paths, and the replacements.
+func FoldCase(paths []FilePath, fileMap map[FileID]FilePath) ([]FilePath, []*PathMatch) {
+	exact := make(map[FilePath]struct{}, len(fileMap))
+	folded := make(map[string][]FilePath, len(fileMap))
+	for _, path := range fileMap {
+		if _, ok := exact[path]; ok {
+			continue
+		}
+		exact[path] = struct{}{}
+		key := strings.ToLower(string(path))
+		folded[key] = append(folded[key], path)
+	}
+
+	var matches []*PathMatch
+	paths = slices.Clone(paths)
+	for i, path := range paths {
+		if _, ok := exact[path]; ok {
+			continue
+		}
+
+		candidates := folded[strings.ToLower(string(path))]
+		if len(candidates) != 1 {
+			continue
+		}
+		matches = append(matches, &PathMatch{Query: path, Path: candidates[0]})
+		paths[i] = candidates[0]
+	}
+
+	return paths, matches
+}
+
+// createQuery returns the query of the paths, and the paths not found in the file map.
+func (t *Tarmaq) createQuery(paths []FilePath, fileMap map[FileID]FilePath) (*Query, []FilePath) {
 	query := &Query{
 		Files: collection.NewSet[FileID](),
 	}
@@ -54,22 +237,24 @@ func (t *Tarmaq) createQuery(paths []FilePath, fileMap map[FileID]FilePath) *Que
 		revFileMap[path] = id
 	}
 
+	var unknown []FilePath
 	for _, path := range paths {
 		id, ok := revFileMap[path]
 		if !ok {
 			slog.Warn("file not found",
 				slog.String("path", string(path)),
 			)
+			unknown = append(unknown, path)
 			continue
 		}
 
 		query.Files.Add(id)
 	}
 
-	return query
+	return query, unknown
 }
 
-func (t *Tarmaq) createResults(rules []*Rule, fileMap map[FileID]FilePath) []*Result {
+func (t *Tarmaq) createResults(rules []*Rule, fileMap map[FileID]FilePath, removed collection.Set[FileID]) []*Result {
 	resultMap := make(map[FileID]*Result)
 	for _, rule := range rules {
 		if _, ok := resultMap[rule.Right]; ok {
@@ -87,10 +272,21 @@ func (t *Tarmaq) createResults(rules []*Rule, fileMap map[FileID]FilePath) []*Re
 		if path == "" {
 			continue
 		}
+
+		var antecedents []FilePath
+		for fileID := range rule.Left.Iter() {
+			if path, ok := fileMap[fileID]; ok {
+				antecedents = append(antecedents, path)
+			}
+		}
+		slices.Sort(antecedents)
+
 		resultMap[rule.Right] = &Result{
-			Path:       path,
-			Confidence: rule.Confidence,
-			Support:    rule.Support,
+			Path:        path,
+			Confidence:  rule.Confidence,
+			Support:     rule.Support,
+			Deleted:     removed.Contains(rule.Right),
+			Antecedents: antecedents,
 		}
 	}
 
@@ -3,6 +3,7 @@ package tarmaq
 import (
 	"testing"
 
+	"github.com/mazrean/mcp-tarmaq/pkg/collection"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -13,6 +14,7 @@ func TestTarmaq_createResults(t *testing.T) {
 		name        string
 		rules       []*Rule
 		fileMap     map[FileID]FilePath
+		removed     collection.Set[FileID]
 		wantResults []*Result
 	}{
 		{
@@ -116,12 +118,69 @@ func TestTarmaq_createResults(t *testing.T) {
 				},
 			},
 		},
+		{
+			name: "Rule with removed file",
+			rules: []*Rule{
+				{
+					Right:      FileID(1),
+					Confidence: 0.8,
+					Support:    10,
+				},
+				{
+					Right:      FileID(2),
+					Confidence: 0.7,
+					Support:    5,
+				},
+			},
+			fileMap: map[FileID]FilePath{
+				FileID(1): NewFilePath("file1.txt"),
+				FileID(2): NewFilePath("file2.txt"),
+			},
+			removed: makeFileSet(FileID(2)),
+			wantResults: []*Result{
+				{
+					Path:       NewFilePath("file1.txt"),
+					Confidence: 0.8,
+					Support:    10,
+				},
+				{
+					Path:       NewFilePath("file2.txt"),
+					Confidence: 0.7,
+					Support:    5,
+					Deleted:    true,
+				},
+			},
+		},
+		{
+			name: "Rule with antecedents",
+			rules: []*Rule{
+				{
+					Left:       makeFileSet(FileID(2), FileID(1)),
+					Right:      FileID(3),
+					Confidence: 0.8,
+					Support:    10,
+				},
+			},
+			fileMap: map[FileID]FilePath{
+				FileID(1): NewFilePath("file1.txt"),
+				FileID(2): NewFilePath("file2.txt"),
+				FileID(3): NewFilePath("file3.txt"),
+			},
+			wantResults: []*Result{
+				{
+					Path:        NewFilePath("file3.txt"),
+					Confidence:  0.8,
+					Support:     10,
+					Antecedents: []FilePath{NewFilePath("file1.txt"), NewFilePath("file2.txt")},
+				},
+			},
+		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			tarmaq := &Tarmaq{}
-			gotResults := tarmaq.createResults(tt.rules, tt.fileMap)
+			gotResults := tarmaq.createResults(tt.rules, tt.fileMap, tt.removed)
 
 			// Map iteration order is non-deterministic, so check element matching
 			assert.ElementsMatch(t, tt.wantResults, gotResults)
@@ -133,10 +192,11 @@ func TestTarmaq_createQuery(t *testing.T) {
 	t.Parallel()
 
 	tests := []struct {
-		name    string
-		paths   []FilePath
-		fileMap map[FileID]FilePath
-		wantIDs []FileID
+		name        string
+		paths       []FilePath
+		fileMap     map[FileID]FilePath
+		wantIDs     []FileID
+		wantUnknown []FilePath
 	}{
 		{
 			name:    "Empty paths list",
@@ -162,7 +222,8 @@ func TestTarmaq_createQuery(t *testing.T) {
 			fileMap: map[FileID]FilePath{
 				FileID(1): NewFilePath("file1.txt"),
 			},
-			wantIDs: []FileID{},
+			wantIDs:     []FileID{},
+			wantUnknown: []FilePath{NewFilePath("nonexistent.txt")},
 		},
 		{
 			name: "Multiple paths that exist in the file map",
@@ -189,14 +250,16 @@ func TestTarmaq_createQuery(t *testing.T) {
 				FileID(2): NewFilePath("file2.txt"),
 				FileID(3): NewFilePath("file3.txt"),
 			},
-			wantIDs: []FileID{1, 3},
+			wantIDs:     []FileID{1, 3},
+			wantUnknown: []FilePath{NewFilePath("nonexistent.txt")},
 		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			tarmaq := &Tarmaq{}
-			got := tarmaq.createQuery(tt.paths, tt.fileMap)
+			got, gotUnknown := tarmaq.createQuery(tt.paths, tt.fileMap)
+			assert.Equal(t, tt.wantUnknown, gotUnknown)
 
 			// Check that each expected ID is in the set
 			for _, id := range tt.wantIDs {
@@ -226,3 +289,91 @@ func TestTarmaq_createQuery(t *testing.T) {
 		})
 	}
 }
+
+func TestTarmaq_Execute_Diagnostics(t *testing.T) {
+	t.Parallel()
+
+	repo := &mockRepository{
+		transactions: []*Transaction{
+			{Files: makeFileSet(FileID(0), FileID(1))},
+			{Files: makeFileSet(FileID(0), FileID(2))},
+			{Files: makeFileSet(FileID(1), FileID(2))},
+			{Files: makeFileSet(FileID(0), FileID(1), FileID(2))},
+		},
+		fileMap: map[FileID]FilePath{
+			FileID(0): NewFilePath("a.go"),
+			FileID(1): NewFilePath("b.go"),
+			FileID(2): NewFilePath("c.go"),
+		},
+	}
+	tarmaq := NewTarmaq(repo,
+		[]TxFilter{NewMaxSizeTxFilter(2), NewTarmaqTxFilter()},
+		NewAssociationRuleExtractor(0, 0),
+	)
+
+	var diagnostics Diagnostics
+	var history History
+	_, err := tarmaq.Execute([]FilePath{NewFilePath("a.go"), NewFilePath("new.go")}, WithDiagnostics(&diagnostics), WithHistory(&history))
+	assert.NoError(t, err)
+
+	assert.Equal(t, History{Transactions: repo.transactions, FileMap: repo.fileMap}, history)
+
+	assert.Equal(t, Diagnostics{
+		MatchedFiles: []FilePath{NewFilePath("a.go")},
+		UnknownFiles: []FilePath{NewFilePath("new.go")},
+		Transactions: 4,
+		TxFilters: []*TxFilterCount{
+			{Filter: "MaxSizeTxFilter", Transactions: 3},
+			{Filter: "TarmaqTxFilter", Transactions: 2},
+		},
+	}, diagnostics)
+}
+
+func TestFoldCase(t *testing.T) {
+	t.Parallel()
+
+	fileMap := map[FileID]FilePath{
+		FileID(0): NewFilePath("src/Main.go"),
+		FileID(1): NewFilePath("README.md"),
+		FileID(2): NewFilePath("readme.md"),
+	}
+
+	tests := []struct {
+		name        string
+		paths       []FilePath
+		wantPaths   []FilePath
+		wantMatches []*PathMatch
+	}{
+		{
+			name:      "Exact match",
+			paths:     []FilePath{NewFilePath("src/Main.go")},
+			wantPaths: []FilePath{NewFilePath("src/Main.go")},
+		},
+		{
+			name:        "Different case",
+			paths:       []FilePath{NewFilePath("SRC/main.go")},
+			wantPaths:   []FilePath{NewFilePath("src/Main.go")},
+			wantMatches: []*PathMatch{{Query: NewFilePath("SRC/main.go"), Path: NewFilePath("src/Main.go")}},
+		},
+		{
+			name:      "Ambiguous",
+			paths:     []FilePath{NewFilePath("Readme.md")},
+			wantPaths: []FilePath{NewFilePath("Readme.md")},
+		},
+		{
+			name:      "Unknown",
+			paths:     []FilePath{NewFilePath("new.go")},
+			wantPaths: []FilePath{NewFilePath("new.go")},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			gotPaths, gotMatches := FoldCase(tt.paths, fileMap)
+			assert.Equal(t, tt.wantPaths, gotPaths)
+			assert.Equal(t, tt.wantMatches, gotMatches)
+		})
+	}
+}
@@ -0,0 +1,176 @@
+package tarmaq
+
+import (
+	"cmp"
+	"path"
+	"slices"
+	"strings"
+)
+
+// languageFamilies maps the extensions of languages sharing tests, such as TypeScript and JavaScript, to a family.
+var languageFamilies = map[string]string{
+	".c": "c", ".h": "c", ".cc": "c", ".cpp": "c", ".cxx": "c", ".hpp": "c",
+	".cs": "dotnet", ".fs": "dotnet", ".vb": "dotnet",
+	".java": "jvm", ".kt": "jvm", ".kts": "jvm", ".scala": "jvm", ".groovy": "jvm",
+	".js": "js", ".jsx": "js", ".mjs": "js", ".cjs": "js", ".ts": "js", ".tsx": "js", ".mts": "js", ".cts": "js", ".vue": "js", ".svelte": "js",
+	".m": "objc", ".mm": "objc", ".swift": "objc",
+	".ex": "elixir", ".exs": "elixir",
+}
+
+// TestPair is a test likely to need updates when a file changes.
+type TestPair struct {
+	// Path is the path of the test.
+	Path FilePath
+	// File is the changed file the test is paired with.
+	File FilePath
+	// NameMatch is true if the test is named after the file in the same or a mirrored directory,
+	// and in the same language, e.g. foo_test.go for foo.go.
+	NameMatch bool
+	// Support is the number of transactions changing both the file and the test.
+	Support uint64
+	// Confidence is the ratio of the transactions changing the file which also changed the test.
+	Confidence float64
+}
+
+// IsTestFile reports whether the path is a test by the naming conventions of common languages.
+func IsTestFile(p FilePath) bool {
+	if slices.ContainsFunc(strings.Split(path.Dir(string(p)), "/"), func(dir string) bool {
+		return dir == "__tests__"
+	}) {
+		return true
+	}
+
+	name := path.Base(string(p))
+	stem := name
+	if i := strings.Index(name[1:], "."); i >= 0 {
+		stem = name[:i+1]
+	}
+	ext := path.Ext(name)
+
+	switch {
+	case strings.HasSuffix(stem, "_test"), strings.HasSuffix(stem, "_spec"):
+		// foo_test.go, foo_test.py, foo_spec.rb
+		return true
+	case strings.HasPrefix(stem, "test_") && ext == ".py":
+		return true
+	case strings.Contains(name, ".test."), strings.Contains(name, ".spec."):
+		// foo.test.ts, foo.spec.js
+		return true
+	case strings.HasSuffix(stem, "Test") || strings.HasSuffix(stem, "Tests") || strings.HasSuffix(stem, "Spec"):
+		// FooTest.java, FooTests.cs, FooSpec.scala
+		return stem != "Test" && stem != "Tests" && stem != "Spec"
+	default:
+		return false
+	}
+}
+
+// TestPairs returns the tests likely to need updates when the files change.
+// Tests are paired with the files by their names in the same or a mirrored directory and language,
+// and by the transactions changing both.
+// Tests among the files and removed tests are omitted. Zero limit means no limit.
+func TestPairs(transactions []*Transaction, fileMap map[FileID]FilePath, files []FilePath, limit int) []*TestPair {
+	removed := RemovedFiles(transactions)
+	revFileMap := make(map[FilePath]FileID, len(fileMap))
+	tests := make(map[FileID]FilePath)
+	for id, p := range fileMap {
+		revFileMap[p] = id
+		if p != "" && IsTestFile(p) && !removed.Contains(id) {
+			tests[id] = p
+		}
+	}
+
+	queried := make(map[FilePath]struct{}, len(files))
+	for _, file := range files {
+		queried[file] = struct{}{}
+	}
+
+	pairMap := make(map[FileID]*TestPair)
+	for _, file := range files {
+		if IsTestFile(file) {
+			continue
+		}
+
+		stem := fileStem(file)
+		language := languageFamily(file)
+		for id, test := range tests {
+			if _, ok := queried[test]; ok || fileStem(test) != stem {
+				continue
+			}
+			if !mirroredDir(test, file) || languageFamily(test) != language {
+				continue
+			}
+			if pair, ok := pairMap[id]; !ok || !pair.NameMatch {
+				pairMap[id] = &TestPair{Path: test, File: file, NameMatch: true}
+			}
+		}
+
+		fileID, ok := revFileMap[file]
+		if !ok {
+			continue
+		}
+
+		var changes uint64
+		supports := make(map[FileID]uint64)
+		for _, tx := range transactions {
+			if !tx.Files.Contains(fileID) {
+				continue
+			}
+			changes++
+			for id := range tx.Files.Iter() {
+				if _, ok := tests[id]; ok {
+					supports[id]++
+				}
+			}
+		}
+
+		for id, support := range supports {
+			if _, ok := queried[tests[id]]; ok {
+				continue
+			}
+
+			confidence := float64(support) / float64(changes)
+			pair, ok := pairMap[id]
+			switch {
+			case !ok:
+				pairMap[id] = &TestPair{Path: tests[id], File: file, Support: support, Confidence: confidence}
+			case pair.File == file || (!pair.NameMatch && confidence > pair.Confidence):
+				pair.File = file
+				pair.Support = support
+				pair.Confidence = confidence
+			}
+		}
+	}
+
+	pairs := make([]*TestPair, 0, len(pairMap))
+	for _, pair := range pairMap {
+		pairs = append(pairs, pair)
+	}
+	slices.SortFunc(pairs, func(a, b *TestPair) int {
+		if a.NameMatch != b.NameMatch {
+			if a.NameMatch {
+				return -1
+			}
+			return 1
+		}
+		return cmp.Or(
+			cmp.Compare(b.Confidence, a.Confidence),
+			cmp.Compare(b.Support, a.Support),
+			cmp.Compare(a.Path, b.Path),
+		)
+	})
+	if limit > 0 && len(pairs) > limit {
+		pairs = pairs[:limit]
+	}
+
+	return pairs
+}
+
+// languageFamily returns the language family of the file by its extension, or the extension of other languages.
+func languageFamily(p FilePath) string {
+	ext := strings.ToLower(path.Ext(string(p)))
+	if family, ok := languageFamilies[ext]; ok {
+		return family
+	}
+
+	return ext
+}
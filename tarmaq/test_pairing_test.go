@@ -0,0 +1,122 @@
+package tarmaq
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIsTestFile(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		path string
+		want bool
+	}{
+		{path: "pkg/foo_test.go", want: true},
+		{path: "pkg/foo.go", want: false},
+		{path: "src/test/java/FooTest.java", want: true},
+		{path: "src/main/java/Foo.java", want: false},
+		{path: "src/main/java/Test.java", want: false},
+		{path: "web/x.spec.ts", want: true},
+		{path: "web/x.test.tsx", want: true},
+		{path: "web/x.ts", want: false},
+		{path: "tests/test_x.py", want: true},
+		{path: "spec/x_spec.rb", want: true},
+		{path: "web/__tests__/x.js", want: true},
+		{path: "docs/testing.md", want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			t.Parallel()
+
+			assert.Equal(t, tt.want, IsTestFile(NewFilePath(tt.path)))
+		})
+	}
+}
+
+func TestTestPairs(t *testing.T) {
+	t.Parallel()
+
+	fileMap := map[FileID]FilePath{
+		FileID(0): NewFilePath("api/user.go"),
+		FileID(1): NewFilePath("api/user_test.go"),
+		FileID(2): NewFilePath("api/router.go"),
+		FileID(3): NewFilePath("e2e/api_test.go"),
+		FileID(4): NewFilePath("api/old_test.go"),
+		FileID(5): NewFilePath("web/__tests__/user.test.ts"),
+	}
+	transactions := []*Transaction{
+		{Files: makeFileSet(FileID(0), FileID(3))},
+		{Files: makeFileSet(FileID(2), FileID(3))},
+		{Files: makeFileSet(FileID(0), FileID(4)), Deleted: makeFileSet(FileID(4))},
+		{Files: makeFileSet(FileID(0), FileID(1), FileID(3))},
+		{Files: makeFileSet(FileID(0))},
+	}
+
+	tests := []struct {
+		name  string
+		files []FilePath
+		limit int
+		want  []*TestPair
+	}{
+		{
+			name:  "Name and history",
+			files: []FilePath{NewFilePath("api/user.go")},
+			want: []*TestPair{
+				{Path: NewFilePath("api/user_test.go"), File: NewFilePath("api/user.go"), NameMatch: true, Support: 1, Confidence: 0.25},
+				{Path: NewFilePath("e2e/api_test.go"), File: NewFilePath("api/user.go"), Support: 2, Confidence: 0.5},
+			},
+		},
+		{
+			name:  "History of several files",
+			files: []FilePath{NewFilePath("api/user.go"), NewFilePath("api/router.go")},
+			want: []*TestPair{
+				{Path: NewFilePath("api/user_test.go"), File: NewFilePath("api/user.go"), NameMatch: true, Support: 1, Confidence: 0.25},
+				{Path: NewFilePath("e2e/api_test.go"), File: NewFilePath("api/router.go"), Support: 1, Confidence: 1},
+			},
+		},
+		{
+			name:  "Limit",
+			files: []FilePath{NewFilePath("api/user.go")},
+			limit: 1,
+			want: []*TestPair{
+				{Path: NewFilePath("api/user_test.go"), File: NewFilePath("api/user.go"), NameMatch: true, Support: 1, Confidence: 0.25},
+			},
+		},
+		{
+			name:  "New file",
+			files: []FilePath{NewFilePath("web/user.ts")},
+			want: []*TestPair{
+				{Path: NewFilePath("web/__tests__/user.test.ts"), File: NewFilePath("web/user.ts"), NameMatch: true},
+			},
+		},
+		{
+			name:  "Same name in another directory",
+			files: []FilePath{NewFilePath("cli/user.go")},
+			want:  []*TestPair{},
+		},
+		{
+			name:  "Same name in another language",
+			files: []FilePath{NewFilePath("api/user.py")},
+			want:  []*TestPair{},
+		},
+		{
+			name:  "Changed test",
+			files: []FilePath{NewFilePath("api/user.go"), NewFilePath("api/user_test.go")},
+			want: []*TestPair{
+				{Path: NewFilePath("e2e/api_test.go"), File: NewFilePath("api/user.go"), Support: 2, Confidence: 0.5},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			got := TestPairs(transactions, fileMap, tt.files, tt.limit)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
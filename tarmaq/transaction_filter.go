@@ -1,9 +1,39 @@
 package tarmaq
 
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/mazrean/mcp-tarmaq/pkg/collection"
+)
+
 type TxFilter interface {
 	Filter(transactions []*Transaction, query *Query) []*Transaction
 }
 
+// ApplyTxFilters applies the filters in order.
+// A nil query is treated as an empty query.
+func ApplyTxFilters(transactions []*Transaction, query *Query, filters []TxFilter) []*Transaction {
+	if query == nil {
+		query = &Query{
+			Files: collection.NewSet[FileID](),
+		}
+	}
+
+	for _, filter := range filters {
+		transactions = filter.Filter(transactions, query)
+	}
+
+	return transactions
+}
+
+// txFilterName returns the type name of the filter without the package.
+func txFilterName(filter TxFilter) string {
+	name := fmt.Sprintf("%T", filter)
+	return name[strings.LastIndex(name, ".")+1:]
+}
+
 var _ TxFilter = &MaxSizeTxFilter{}
 
 type MaxSizeTxFilter struct {
@@ -56,3 +86,105 @@ func (f *TarmaqTxFilter) Filter(transactions []*Transaction, query *Query) []*Tr
 
 	return filtered
 }
+
+var _ TxFilter = &AuthorTxFilter{}
+
+// AuthorTxFilter keeps the transactions authored or committed by the authors,
+// to mine the companion changes of a person or a team.
+// Email addresses are compared ignoring case, after mapping their aliases with the mailmap.
+type AuthorTxFilter struct {
+	Authors collection.Set[string]
+	Mailmap *Mailmap
+}
+
+// NewAuthorTxFilter creates a filter of the email addresses of the authors. The mailmap can be nil.
+func NewAuthorTxFilter(authors []string, mailmap *Mailmap) *AuthorTxFilter {
+	set := collection.NewSet[string]()
+	for _, author := range authors {
+		set.Add(mailmap.Email(author))
+	}
+
+	return &AuthorTxFilter{
+		Authors: set,
+		Mailmap: mailmap,
+	}
+}
+
+func (f *AuthorTxFilter) Filter(transactions []*Transaction, _ *Query) []*Transaction {
+	filtered := make([]*Transaction, 0, len(transactions))
+
+	for _, tx := range transactions {
+		if (tx.Author != "" && f.Authors.Contains(f.Mailmap.Email(tx.Author))) ||
+			(tx.Committer != "" && f.Authors.Contains(f.Mailmap.Email(tx.Committer))) {
+			filtered = append(filtered, tx)
+		}
+	}
+
+	return filtered
+}
+
+var _ TxFilter = &MessageTxFilter{}
+
+// MessageTxFilter drops the transactions whose messages match any of the patterns,
+// such as dependency updates and formatting, whose files change together only mechanically.
+type MessageTxFilter struct {
+	Patterns []*regexp.Regexp
+}
+
+func NewMessageTxFilter(patterns ...*regexp.Regexp) *MessageTxFilter {
+	return &MessageTxFilter{
+		Patterns: patterns,
+	}
+}
+
+func (f *MessageTxFilter) Filter(transactions []*Transaction, _ *Query) []*Transaction {
+	filtered := make([]*Transaction, 0, len(transactions))
+
+	for _, tx := range transactions {
+		matched := false
+		for _, pattern := range f.Patterns {
+			if pattern.MatchString(tx.Message) {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			filtered = append(filtered, tx)
+		}
+	}
+
+	return filtered
+}
+
+// DefaultBotPattern matches the email addresses of bots such as Dependabot, Renovate and GitHub Actions.
+var DefaultBotPattern = regexp.MustCompile(`(?i)\[bot\]@|^([0-9]+\+)?(dependabot|renovate|greenkeeper|snyk-bot|github-actions)\b|@renovateapp\.com$`)
+
+var _ TxFilter = &BotTxFilter{}
+
+// BotTxFilter drops the transactions authored by bots, whose mass updates couple unrelated files.
+type BotTxFilter struct {
+	Pattern *regexp.Regexp
+}
+
+// NewBotTxFilter creates a filter of the authors matching the pattern, or DefaultBotPattern if it is nil.
+func NewBotTxFilter(pattern *regexp.Regexp) *BotTxFilter {
+	if pattern == nil {
+		pattern = DefaultBotPattern
+	}
+
+	return &BotTxFilter{
+		Pattern: pattern,
+	}
+}
+
+func (f *BotTxFilter) Filter(transactions []*Transaction, _ *Query) []*Transaction {
+	filtered := make([]*Transaction, 0, len(transactions))
+
+	for _, tx := range transactions {
+		if !f.Pattern.MatchString(tx.Author) {
+			filtered = append(filtered, tx)
+		}
+	}
+
+	return filtered
+}
@@ -1,6 +1,7 @@
 package tarmaq
 
 import (
+	"regexp"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -266,3 +267,143 @@ func TestTarmaqTxFilter_Filter(t *testing.T) {
 		})
 	}
 }
+
+func TestAuthorTxFilter_Filter(t *testing.T) {
+	t.Parallel()
+
+	mailmap := NewMailmap()
+	mailmap.Add("alice@old.example.com", "alice@example.com")
+
+	transactions := []*Transaction{
+		{ID: "1", Author: "alice@example.com", Files: makeFileSet(FileID(0))},
+		{ID: "2", Author: "Alice@Old.example.com", Files: makeFileSet(FileID(0))},
+		{ID: "3", Author: "bob@example.com", Committer: "alice@example.com", Files: makeFileSet(FileID(0))},
+		{ID: "4", Author: "bob@example.com", Committer: "bob@example.com", Files: makeFileSet(FileID(0))},
+		{ID: "5", Files: makeFileSet(FileID(0))},
+	}
+
+	tests := []struct {
+		name    string
+		authors []string
+		mailmap *Mailmap
+		wantIDs []string
+	}{
+		{
+			name:    "Author and committer",
+			authors: []string{"alice@example.com"},
+			wantIDs: []string{"1", "3"},
+		},
+		{
+			name:    "Mailmap",
+			authors: []string{"alice@example.com"},
+			mailmap: mailmap,
+			wantIDs: []string{"1", "2", "3"},
+		},
+		{
+			name:    "Alias of the author",
+			authors: []string{"ALICE@old.example.com"},
+			mailmap: mailmap,
+			wantIDs: []string{"1", "2", "3"},
+		},
+		{
+			name:    "Several authors",
+			authors: []string{"alice@example.com", "bob@example.com"},
+			wantIDs: []string{"1", "3", "4"},
+		},
+		{
+			name:    "No authors",
+			wantIDs: []string{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			got := NewAuthorTxFilter(tt.authors, tt.mailmap).Filter(transactions, &Query{Files: makeFileSet()})
+			gotIDs := make([]string, 0, len(got))
+			for _, tx := range got {
+				gotIDs = append(gotIDs, tx.ID)
+			}
+			assert.Equal(t, tt.wantIDs, gotIDs)
+		})
+	}
+}
+
+func TestMessageTxFilter_Filter(t *testing.T) {
+	t.Parallel()
+
+	transactions := []*Transaction{
+		{ID: "1", Message: "Add login page\n", Files: makeFileSet(FileID(0))},
+		{ID: "2", Message: "chore(deps): bump golang.org/x/net\n", Files: makeFileSet(FileID(0))},
+		{ID: "3", Message: "Merge branch 'main' into feature\n", Files: makeFileSet(FileID(0))},
+		{ID: "4", Message: "Fix session\n\nAlso update the license header\n", Files: makeFileSet(FileID(0))},
+	}
+
+	tests := []struct {
+		name     string
+		patterns []string
+		wantIDs  []string
+	}{
+		{
+			name:    "No patterns",
+			wantIDs: []string{"1", "2", "3", "4"},
+		},
+		{
+			name:     "Prefix",
+			patterns: []string{`^chore\(deps\)`},
+			wantIDs:  []string{"1", "3", "4"},
+		},
+		{
+			name:     "Several patterns",
+			patterns: []string{`^chore\(deps\)`, `Merge branch`, `(?i)license header`},
+			wantIDs:  []string{"1"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			patterns := make([]*regexp.Regexp, 0, len(tt.patterns))
+			for _, pattern := range tt.patterns {
+				patterns = append(patterns, regexp.MustCompile(pattern))
+			}
+
+			got := NewMessageTxFilter(patterns...).Filter(transactions, &Query{Files: makeFileSet()})
+			gotIDs := make([]string, 0, len(got))
+			for _, tx := range got {
+				gotIDs = append(gotIDs, tx.ID)
+			}
+			assert.Equal(t, tt.wantIDs, gotIDs)
+		})
+	}
+}
+
+func TestBotTxFilter_Filter(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		author string
+		bot    bool
+	}{
+		{author: "alice@example.com"},
+		{author: "49699333+dependabot[bot]@users.noreply.github.com", bot: true},
+		{author: "29139614+renovate[bot]@users.noreply.github.com", bot: true},
+		{author: "bot@renovateapp.com", bot: true},
+		{author: "41898282+github-actions[bot]@users.noreply.github.com", bot: true},
+		{author: "dependabot", bot: true},
+		{author: "renovated@example.com"},
+		{author: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.author, func(t *testing.T) {
+			t.Parallel()
+
+			transactions := []*Transaction{{Author: tt.author, Files: makeFileSet(FileID(0))}}
+			got := NewBotTxFilter(nil).Filter(transactions, &Query{Files: makeFileSet()})
+			assert.Equal(t, tt.bot, len(got) == 0)
+		})
+	}
+}
@@ -0,0 +1,34 @@
+package tarmaq
+
+import (
+	"path"
+	"slices"
+	"strings"
+)
+
+// WorktreeReporter is implemented by repositories with a working tree.
+type WorktreeReporter interface {
+	// ChangedFiles returns the files with uncommitted changes in the working tree, including untracked files.
+	// Paths are relative to the subdirectory, and files outside of it are omitted.
+	ChangedFiles() ([]FilePath, error)
+}
+
+// parseGitStatus parses the output of git status --porcelain -z into the paths of changed files.
+func parseGitStatus(out string) []string {
+	var paths []string
+	entries := strings.Split(out, "\x00")
+	for i := 0; i < len(entries); i++ {
+		// XY <path>, followed by \0<original path> for renames and copies
+		entry := entries[i]
+		if len(entry) < 4 {
+			continue
+		}
+		paths = append(paths, path.Clean(entry[3:]))
+		if entry[0] == 'R' || entry[0] == 'C' {
+			i++
+		}
+	}
+	slices.Sort(paths)
+
+	return slices.Compact(paths)
+}